@@ -0,0 +1,254 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfortools
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// groupby returns a new slice of struct{ Key interface{}; Values []T },
+// one element for each distinct value of field found in data, a slice or
+// array of structs of type T.  Values preserves the relative order in
+// which the elements appeared in data.  The resulting slice can be ranged
+// over, or passed to helpers such as sort, head and tablex.
+func groupby(data interface{}, field string) (interface{}, error) {
+	v, err := sliceValue(data, "groupby")
+	if err != nil {
+		return nil, err
+	}
+	if v.Len() == 0 {
+		return []struct{}{}, nil
+	}
+
+	elemType := v.Index(0).Type()
+	if _, ok := elemType.FieldByName(field); !ok {
+		return nil, fmt.Errorf("tfortools: groupby: no such field %s", field)
+	}
+
+	groupType := reflect.StructOf([]reflect.StructField{
+		{Name: "Key", Type: reflect.TypeOf((*interface{})(nil)).Elem()},
+		{Name: "Values", Type: reflect.SliceOf(elemType)},
+	})
+
+	index := map[interface{}]int{}
+	result := reflect.MakeSlice(reflect.SliceOf(groupType), 0, 0)
+	for i := 0; i < v.Len(); i++ {
+		fv, err := fieldByName(v.Index(i), field)
+		if err != nil {
+			return nil, err
+		}
+		key := fv.Interface()
+
+		idx, ok := index[key]
+		if !ok {
+			idx = result.Len()
+			index[key] = idx
+
+			group := reflect.New(groupType).Elem()
+			group.FieldByName("Key").Set(fv)
+			group.FieldByName("Values").Set(reflect.MakeSlice(reflect.SliceOf(elemType), 0, 0))
+			result = reflect.Append(result, group)
+		}
+
+		values := result.Index(idx).FieldByName("Values")
+		values.Set(reflect.Append(values, v.Index(i)))
+	}
+
+	return result.Interface(), nil
+}
+
+// distinct returns a new slice containing the distinct values of field
+// found in data, a slice or array of structs, in the order in which they
+// first appear.
+func distinct(data interface{}, field string) (interface{}, error) {
+	v, err := sliceValue(data, "distinct")
+	if err != nil {
+		return nil, err
+	}
+	if v.Len() == 0 {
+		return []struct{}{}, nil
+	}
+
+	elemType := v.Index(0).Type()
+	sf, ok := elemType.FieldByName(field)
+	if !ok {
+		return nil, fmt.Errorf("tfortools: distinct: no such field %s", field)
+	}
+
+	seen := map[interface{}]bool{}
+	result := reflect.MakeSlice(reflect.SliceOf(sf.Type), 0, 0)
+	for i := 0; i < v.Len(); i++ {
+		fv, err := fieldByName(v.Index(i), field)
+		if err != nil {
+			return nil, err
+		}
+		key := fv.Interface()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = reflect.Append(result, fv)
+	}
+	return result.Interface(), nil
+}
+
+// count returns the number of elements in data, a slice or array.
+func count(data interface{}) (int, error) {
+	v, err := sliceValue(data, "count")
+	if err != nil {
+		return 0, err
+	}
+	return v.Len(), nil
+}
+
+// numericField locates field within each element of data and reports
+// whether any of the values it finds are floating point, as well as the
+// slice value itself.
+func numericField(data interface{}, fn, field string) (reflect.Value, error) {
+	v, err := sliceValue(data, fn)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if v.Len() == 0 {
+		return reflect.Value{}, fmt.Errorf("tfortools: %s: data is empty", fn)
+	}
+	if _, ok := v.Index(0).Type().FieldByName(field); !ok {
+		return reflect.Value{}, fmt.Errorf("tfortools: %s: no such field %s", fn, field)
+	}
+	return v, nil
+}
+
+// sum walks field across every element of data (int, uint and float
+// variants are all supported, via reflect) and returns the widened numeric
+// result: float64 for floating point fields, int64 for signed integer
+// fields and uint64 for unsigned integer fields.
+func sum(data interface{}, field string) (interface{}, error) {
+	v, err := numericField(data, "sum", field)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		fsum       float64
+		isum       int64
+		usum       uint64
+		isFloat    bool
+		isSigned   bool
+		isUnsigned bool
+	)
+	for i := 0; i < v.Len(); i++ {
+		fv, err := fieldByName(v.Index(i), field)
+		if err != nil {
+			return nil, err
+		}
+		switch fv.Kind() {
+		case reflect.Float32, reflect.Float64:
+			isFloat = true
+			fsum += fv.Float()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			isSigned = true
+			isum += fv.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			isUnsigned = true
+			usum += fv.Uint()
+		default:
+			return nil, fmt.Errorf("tfortools: sum: field %s is not numeric", field)
+		}
+	}
+
+	switch {
+	case isFloat:
+		return fsum + float64(isum) + float64(usum), nil
+	case isSigned:
+		return isum, nil
+	case isUnsigned:
+		return usum, nil
+	default:
+		return int64(0), nil
+	}
+}
+
+// avg returns the mean value of field across every element of data.
+func avg(data interface{}, field string) (float64, error) {
+	v, err := numericField(data, "avg", field)
+	if err != nil {
+		return 0, err
+	}
+
+	var total float64
+	for i := 0; i < v.Len(); i++ {
+		fv, err := fieldByName(v.Index(i), field)
+		if err != nil {
+			return 0, err
+		}
+		f, err := toFloat64(fv)
+		if err != nil {
+			return 0, fmt.Errorf("tfortools: avg: %v", err)
+		}
+		total += f
+	}
+	return total / float64(v.Len()), nil
+}
+
+func toFloat64(v reflect.Value) (float64, error) {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), nil
+	default:
+		return 0, fmt.Errorf("field is not numeric, got %s", v.Kind())
+	}
+}
+
+// minmax returns the smallest (max == false) or largest (max == true)
+// value of field found across every element of data.  It works on any
+// field whose kind is a numeric or string type.
+func minmax(data interface{}, fn, field string, max bool) (interface{}, error) {
+	v, err := numericField(data, fn, field)
+	if err != nil {
+		return nil, err
+	}
+
+	best, err := fieldByName(v.Index(0), field)
+	if err != nil {
+		return nil, err
+	}
+	for i := 1; i < v.Len(); i++ {
+		fv, err := fieldByName(v.Index(i), field)
+		if err != nil {
+			return nil, err
+		}
+		if max {
+			if less(best, fv) {
+				best = fv
+			}
+		} else if less(fv, best) {
+			best = fv
+		}
+	}
+	return best.Interface(), nil
+}
+
+func minFn(data interface{}, field string) (interface{}, error) {
+	return minmax(data, "min", field, false)
+}
+
+func maxFn(data interface{}, field string) (interface{}, error) {
+	return minmax(data, "max", field, true)
+}
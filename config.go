@@ -0,0 +1,178 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfortools
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"text/template"
+	"time"
+)
+
+// Option configures a Config, adding one or more template functions to the
+// set that OutputToTemplate makes available to a script.  Options are
+// applied, in order, by NewConfig.
+type Option func(*Config)
+
+// Config stores the set of template functions, and their associated help
+// text, that have been enabled for use with OutputToTemplate and the usage
+// generation functions, GenerateUsageDecorated and TemplateFunctionHelpSingle.
+type Config struct {
+	funcMap template.FuncMap
+	names   []string
+	help    map[string]string
+
+	// MaxBufferedRows bounds how many elements a blocking template
+	// function, such as sort, groupby or tojson, will read from an
+	// Iterator passed to OutputToTemplateStream before giving up with
+	// ErrTooManyRows.  Zero, the default, means unlimited.  It has no
+	// effect on data supplied as an ordinary slice or array.
+	MaxBufferedRows int
+
+	// MaxExecutionTime bounds how long OutputToTemplate and
+	// OutputToTemplateContext will wait for a script before giving up and
+	// returning ErrTemplateTimeout.  Zero, the default, means unlimited.
+	//
+	// It bounds latency, not resource consumption: the script itself is
+	// merely abandoned once the deadline passes, not stopped, so a script
+	// that never finishes (an infinite loop in a custom function, a
+	// pathological regexp passed to filterRegexp) goes on consuming CPU,
+	// and writing to the destination io.Writer, on its own goroutine
+	// indefinitely. A caller that retries after a timeout can accumulate
+	// one such goroutine per attempt. See OutputToTemplateContext.
+	MaxExecutionTime time.Duration
+
+	// MaxOutputBytes bounds how many bytes of output OutputToTemplate
+	// and OutputToTemplateContext will write before giving up and
+	// returning ErrOutputTruncated.  Whatever was produced up to that
+	// point is still written to w.  Zero, the default, means unlimited.
+	MaxOutputBytes int64
+
+	// MaxRangeIterations bounds how many elements an Iterator passed to
+	// OutputToTemplateStream may yield during one template execution
+	// before it is cut short with ErrTooManyRangeIterations.  Zero, the
+	// default, means unlimited.
+	MaxRangeIterations int
+
+	// MaxTemplateDepth bounds how many levels deep the tojson template
+	// function will recurse into the fields of its argument before
+	// giving up with ErrMaxDepthExceeded.  Zero, the default, means
+	// unlimited.
+	MaxTemplateDepth int
+}
+
+// NewConfig creates a new Config and applies opts to it, in order.  The
+// functions added by opts, along with any functions registered later via
+// AddCustomFn, are the only ones made available to templates executed with
+// this Config.  Passing no options returns a Config with no template
+// functions enabled; OptAllFns can be used to enable them all.
+func NewConfig(opts ...Option) *Config {
+	cfg := &Config{
+		funcMap: template.FuncMap{},
+		help:    map[string]string{},
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+func (cfg *Config) addFn(name, help string, fn interface{}) {
+	if _, ok := cfg.funcMap[name]; !ok {
+		cfg.names = append(cfg.names, name)
+	}
+	cfg.funcMap[name] = fn
+	cfg.help[name] = help
+}
+
+// AddCustomFn registers a new template function, fn, under the given name,
+// along with the help text that describes it.  fn becomes available to any
+// template executed via OutputToTemplate with this Config, and help is
+// included, verbatim, in the text produced by GenerateUsageDecorated and
+// returned by TemplateFunctionHelpSingle.  AddCustomFn returns an error if
+// name is already registered or if fn is not a function.
+func (cfg *Config) AddCustomFn(fn interface{}, name, help string) error {
+	if reflect.ValueOf(fn).Kind() != reflect.Func {
+		return fmt.Errorf("tfortools: fn must be a function, got %s", reflect.TypeOf(fn))
+	}
+	if _, ok := cfg.funcMap[name]; ok {
+		return fmt.Errorf("tfortools: a function called %s has already been registered", name)
+	}
+	cfg.addFn(name, help, fn)
+	return nil
+}
+
+// wrapSerializer adapts a writer based serializer function, as passed to
+// AddCustomSerializer, into the string returning form expected of a
+// template function such as tojson.  data is drained first if it is a
+// *stream, so every serializer, including custom ones registered via
+// AddCustomSerializer, works the same way under OutputToTemplateStream as
+// it does under OutputToTemplate.
+func wrapSerializer(fn func(io.Writer, interface{}) error) func(interface{}) (string, error) {
+	return func(data interface{}) (string, error) {
+		data, err := drainIfStream(data)
+		if err != nil {
+			return "", err
+		}
+		var buf bytes.Buffer
+		if err := fn(&buf, data); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+}
+
+// AddCustomSerializer registers a new output serializer, fn, under the
+// given name, making it available as a template function, in the same way
+// as tojson, toyaml and tocsv.  Unlike AddCustomFn, callers don't need to
+// wrap their serializer in a function that builds a string: fn writes
+// directly to an io.Writer, and AddCustomSerializer takes care of
+// buffering its output for use inside a template.  AddCustomSerializer
+// returns an error if name is already registered.
+func (cfg *Config) AddCustomSerializer(name string, fn func(io.Writer, interface{}) error) error {
+	if _, ok := cfg.funcMap[name]; ok {
+		return fmt.Errorf("tfortools: a function called %s has already been registered", name)
+	}
+	help := fmt.Sprintf("- '%s' renders its argument using a custom serializer, e.g.,\n\n  {{%s .}}", name, name)
+	cfg.addFn(name, help, wrapSerializer(fn))
+	return nil
+}
+
+// TemplateFunctionNames returns the names of all the template functions
+// enabled for cfg, in the order in which they were added.
+func TemplateFunctionNames(cfg *Config) []string {
+	if cfg == nil {
+		cfg = NewConfig(OptAllFns)
+	}
+	names := make([]string, len(cfg.names))
+	copy(names, cfg.names)
+	return names
+}
+
+// TemplateFunctionHelpSingle returns the help text registered for the
+// template function called name.  It returns an error if no such function
+// has been enabled for cfg.
+func TemplateFunctionHelpSingle(name string, cfg *Config) (string, error) {
+	if cfg == nil {
+		cfg = NewConfig(OptAllFns)
+	}
+	help, ok := cfg.help[name]
+	if !ok {
+		return "", fmt.Errorf("tfortools: unknown template function %s", name)
+	}
+	return help + "\n", nil
+}
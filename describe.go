@@ -0,0 +1,150 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfortools
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// tagRegexp matches the key:"value" pairs that make up a struct tag, as
+// documented by reflect.StructTag.
+var tagRegexp = regexp.MustCompile(`([a-zA-Z_][a-zA-Z0-9_]*):"((?:[^"\\]|\\.)*)"`)
+
+// splitTag pulls the tfortools tag, if present, out of tag, returning the
+// help text it contains along with the remaining tags, reassembled in their
+// original order.
+func splitTag(tag string) (otherTags, help string) {
+	matches := tagRegexp.FindAllStringSubmatch(tag, -1)
+	var kept []string
+	for _, m := range matches {
+		if m[1] == "tfortools" {
+			help = m[2]
+			continue
+		}
+		kept = append(kept, m[1]+`:"`+m[2]+`"`)
+	}
+	if len(kept) > 0 {
+		otherTags = "`" + strings.Join(kept, " ") + "`"
+	}
+	return otherTags, help
+}
+
+// validField reports whether f should appear in the output of describeType,
+// GenerateUsageUndecorated and the field lists used by cols, tablex, and
+// friends.  Unexported fields and fields whose type cannot sensibly be
+// rendered or passed through a template are excluded.
+func validField(f reflect.StructField) bool {
+	if f.PkgPath != "" {
+		return false
+	}
+	switch f.Type.Kind() {
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer, reflect.Invalid:
+		return false
+	}
+	return true
+}
+
+// fieldInfo captures everything needed to render a single struct field in
+// the output of describeType.
+type fieldInfo struct {
+	name string
+	typ  string
+	tag  string
+	help string
+}
+
+func structFieldInfo(t reflect.Type) []fieldInfo {
+	fields := make([]fieldInfo, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !validField(f) {
+			continue
+		}
+		tag, help := splitTag(string(f.Tag))
+		if _, isFmt := fmtTagVerb(f); isFmt {
+			help = ""
+		}
+		if _, isTitle := titleTagValue(f); isTitle {
+			help = ""
+		}
+		fields = append(fields, fieldInfo{
+			name: f.Name,
+			typ:  describeType(f.Type),
+			tag:  tag,
+			help: help,
+		})
+	}
+	return fields
+}
+
+// describeType returns a human readable, Go-like, description of t.  Struct
+// types are expanded recursively so that callers can see every field they
+// might reference from a template.
+func describeType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return "[]" + describeType(t.Elem())
+	case reflect.Ptr:
+		return "*" + describeType(t.Elem())
+	case reflect.Struct:
+		return describeStruct(t)
+	default:
+		return t.String()
+	}
+}
+
+func describeStruct(t reflect.Type) string {
+	fields := structFieldInfo(t)
+	if len(fields) == 0 {
+		return "struct {\n}"
+	}
+
+	var nameWidth, typeWidth int
+	for _, f := range fields {
+		if len(f.name) > nameWidth {
+			nameWidth = len(f.name)
+		}
+		if len(f.typ) > typeWidth {
+			typeWidth = len(f.typ)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	for _, f := range fields {
+		b.WriteByte('\t')
+		b.WriteString(f.name)
+		b.WriteString(strings.Repeat(" ", nameWidth-len(f.name)+1))
+		b.WriteString(f.typ)
+		if f.tag != "" || f.help != "" {
+			b.WriteString(strings.Repeat(" ", typeWidth-len(f.typ)+1))
+			if f.tag != "" {
+				b.WriteString(f.tag)
+				if f.help != "" {
+					b.WriteByte(' ')
+				}
+			}
+			if f.help != "" {
+				b.WriteString("// ")
+				b.WriteString(f.help)
+			}
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
@@ -0,0 +1,26 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tfortools contains a number of functions that make it easier
+// for applications to implement the -f or --format option.  This option
+// allows end users to extract a subset of information from a command's
+// output and to present that information in an arbitrary format, using
+// Go's text/template package.
+//
+// tfortools adds a number of extra functions to the set already
+// provided by text/template, such as functions for filtering, sorting,
+// selecting and formatting data.  It also provides helpers for
+// generating usage text that describes these extra functions along
+// with the structure of the data that a given template operates on.
+package tfortools
@@ -18,8 +18,10 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"time"
 )
 
 func ExampleGenerateUsageDecorated() {
@@ -584,21 +586,546 @@ func ExampleConfig_AddCustomFn() {
 	nums := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
 	cfg := NewConfig(OptAllFns)
 	err := cfg.AddCustomFn(func(n []int) int {
-		sum := 0
+		total := 0
 		for _, num := range n {
-			sum += num
+			total += num
 		}
-		return sum
-	}, "sum", "- sum \"Returns\" the sum of a slice of integers")
+		return total
+	}, "total", "- total \"Returns\" the sum of a slice of integers")
 	if err != nil {
 		panic(err)
 	}
 
 	// Print the sum of a slice of numbers
-	script := `{{println (sum .)}}`
+	script := `{{println (total .)}}`
 	if err = OutputToTemplate(os.Stdout, "sums", script, nums, cfg); err != nil {
 		panic(err)
 	}
 	// output:
 	// 55
 }
+
+func ExampleOptGroupBy() {
+	data := []struct{ FirstName, MiddleName, Surname string }{
+		{"Marcus", "Tullius", "Cicero"},
+		{"Gaius", "Julius", "Caesar"},
+		{"Marcus", "Licinius", "Crassus"},
+	}
+
+	// Group the records by first name and print out the surnames that
+	// belong to each group.
+	script := `{{range (sort (groupby . "FirstName") "Key")}}{{.Key}}:{{range .Values}} {{.Surname}}{{end}}{{println}}{{end}}`
+	if err := OutputToTemplate(os.Stdout, "names", script, data, nil); err != nil {
+		panic(err)
+	}
+	// output:
+	// Gaius: Caesar
+	// Marcus: Cicero Crassus
+}
+
+func ExampleOptSum() {
+	data := []struct {
+		Name   string
+		Battle int
+	}{
+		{"Caesar", 55},
+		{"Alexander", 32},
+	}
+
+	// Print the total number of battles fought by everyone in the slice.
+	script := `{{sum . "Battle"}}`
+	if err := OutputToTemplate(os.Stdout, "names", script, data, nil); err != nil {
+		panic(err)
+	}
+	// output:
+	// 87
+}
+
+func ExampleOptAvg() {
+	data := []struct {
+		Name   string
+		Battle int
+	}{
+		{"Caesar", 55},
+		{"Alexander", 32},
+	}
+
+	// Print the average number of battles fought by everyone in the slice.
+	script := `{{avg . "Battle"}}`
+	if err := OutputToTemplate(os.Stdout, "names", script, data, nil); err != nil {
+		panic(err)
+	}
+	// output:
+	// 43.5
+}
+
+func ExampleOptMin() {
+	data := []struct {
+		Name   string
+		Battle int
+	}{
+		{"Caesar", 55},
+		{"Alexander", 32},
+	}
+
+	// Print the smallest number of battles fought by anyone in the slice.
+	script := `{{min . "Battle"}}`
+	if err := OutputToTemplate(os.Stdout, "names", script, data, nil); err != nil {
+		panic(err)
+	}
+	// output:
+	// 32
+}
+
+func ExampleOptMax() {
+	data := []struct {
+		Name   string
+		Battle int
+	}{
+		{"Caesar", 55},
+		{"Alexander", 32},
+	}
+
+	// Print the largest number of battles fought by anyone in the slice.
+	script := `{{max . "Battle"}}`
+	if err := OutputToTemplate(os.Stdout, "names", script, data, nil); err != nil {
+		panic(err)
+	}
+	// output:
+	// 55
+}
+
+func ExampleOptDistinct() {
+	data := []struct{ FirstName, MiddleName, Surname string }{
+		{"Marcus", "Tullius", "Cicero"},
+		{"Gaius", "Julius", "Caesar"},
+		{"Marcus", "Licinius", "Crassus"},
+	}
+
+	// Print the distinct first names found in the slice.
+	script := `{{range (distinct . "FirstName")}}{{println .}}{{end}}`
+	if err := OutputToTemplate(os.Stdout, "names", script, data, nil); err != nil {
+		panic(err)
+	}
+	// output:
+	// Marcus
+	// Gaius
+}
+
+func ExampleOptCount() {
+	data := []struct{ FirstName, MiddleName, Surname string }{
+		{"Marcus", "Tullius", "Cicero"},
+		{"Gaius", "Julius", "Caesar"},
+		{"Marcus", "Licinius", "Crassus"},
+	}
+
+	// Print the number of people whose first name is Marcus.
+	script := `{{count (filter . "FirstName" "Marcus")}}`
+	if err := OutputToTemplate(os.Stdout, "names", script, data, nil); err != nil {
+		panic(err)
+	}
+	// output:
+	// 2
+}
+
+func ExampleOptTableX_groupby() {
+	data := []struct{ FirstName, MiddleName, Surname string }{
+		{"Marcus", "Tullius", "Cicero"},
+		{"Gaius", "Julius", "Caesar"},
+		{"Marcus", "Licinius", "Crassus"},
+	}
+
+	// Group the data by first name and print out one table row per group,
+	// combined with sort and head to show how the new aggregation helpers
+	// compose with the existing ones.
+	script := `{{tablex (groupby . "FirstName") 12 8 0}}`
+	var b bytes.Buffer
+	if err := OutputToTemplate(&b, "names", script, data, nil); err != nil {
+		panic(err)
+	}
+
+	scanner := bufio.NewScanner(&b)
+	for scanner.Scan() {
+		fmt.Println(strings.TrimSpace(scanner.Text()))
+	}
+	// output:
+	// Key         Values
+	// Marcus      [{Marcus Tullius Cicero} {Marcus Licinius Crassus}]
+	// Gaius       [{Gaius Julius Caesar}]
+}
+
+func ExampleOptToYAML() {
+	data := []struct {
+		Name       string
+		AgeAtDeath int
+		Battles    []string
+	}{
+		{"Caesar", 55, []string{"Alesia", "Dyrrhachium"}},
+		{"Alexander", 32, []string{"Issus", "Gaugamela"}},
+	}
+
+	script := `{{toyaml .}}`
+	if err := OutputToTemplate(os.Stdout, "names", script, data, nil); err != nil {
+		panic(err)
+	}
+	// output:
+	// -
+	//   Name: "Caesar"
+	//   AgeAtDeath: 55
+	//   Battles:
+	//     - "Alesia"
+	//     - "Dyrrhachium"
+	// -
+	//   Name: "Alexander"
+	//   AgeAtDeath: 32
+	//   Battles:
+	//     - "Issus"
+	//     - "Gaugamela"
+}
+
+func ExampleOptToTOML() {
+	data := []struct {
+		Name       string
+		AgeAtDeath int
+	}{
+		{"Caesar", 55},
+		{"Alexander", 32},
+	}
+
+	script := `{{totoml .}}`
+	if err := OutputToTemplate(os.Stdout, "names", script, data, nil); err != nil {
+		panic(err)
+	}
+	// output:
+	// [[item]]
+	// Name = "Caesar"
+	// AgeAtDeath = 55
+	//
+	// [[item]]
+	// Name = "Alexander"
+	// AgeAtDeath = 32
+}
+
+func ExampleOptToCSV() {
+	data := []struct {
+		Name       string
+		AgeAtDeath int
+	}{
+		{"Caesar", 55},
+		{"Alexander", 32},
+	}
+
+	script := `{{tocsv .}}`
+	if err := OutputToTemplate(os.Stdout, "names", script, data, nil); err != nil {
+		panic(err)
+	}
+	// output:
+	// Name,AgeAtDeath
+	// Caesar,55
+	// Alexander,32
+}
+
+func ExampleOptToCSV_title() {
+	data := []struct {
+		Name       string `tfortools:"The deceased's name"`
+		AgeAtDeath int    `tfortools:"title=Age"`
+	}{
+		{"Caesar", 55},
+		{"Alexander", 32},
+	}
+
+	// Name keeps its ordinary tfortools help text, which plays no part in
+	// tocsv's header row; AgeAtDeath pins its own header via title=.
+	script := `{{tocsv .}}`
+	if err := OutputToTemplate(os.Stdout, "names", script, data, nil); err != nil {
+		panic(err)
+	}
+	// output:
+	// Name,Age
+	// Caesar,55
+	// Alexander,32
+}
+
+func ExampleOptToTSV() {
+	data := []struct {
+		Name       string
+		AgeAtDeath int
+	}{
+		{"Caesar", 55},
+		{"Alexander", 32},
+	}
+
+	script := `{{totsv .}}`
+	if err := OutputToTemplate(os.Stdout, "names", script, data, nil); err != nil {
+		panic(err)
+	}
+	// output:
+	// Name	AgeAtDeath
+	// Caesar	55
+	// Alexander	32
+}
+
+func ExampleOptToXML() {
+	data := []struct {
+		Name       string
+		AgeAtDeath int
+	}{
+		{"Caesar", 55},
+		{"Alexander", 32},
+	}
+
+	script := `{{toxml .}}`
+	if err := OutputToTemplate(os.Stdout, "names", script, data, nil); err != nil {
+		panic(err)
+	}
+	// output:
+	// <items>
+	// 	<item>
+	// 		<Name>Caesar</Name>
+	// 		<AgeAtDeath>55</AgeAtDeath>
+	// 	</item>
+	// 	<item>
+	// 		<Name>Alexander</Name>
+	// 		<AgeAtDeath>32</AgeAtDeath>
+	// 	</item>
+	// </items>
+}
+
+func ExampleOptTableX_textMarshaler() {
+	data := []struct {
+		Name    string
+		Started time.Time
+	}{
+		{"web", time.Date(2017, 6, 15, 10, 30, 0, 0, time.UTC)},
+		{"db", time.Date(2017, 6, 15, 10, 31, 0, 0, time.UTC)},
+	}
+
+	script := `{{tablex . 12 8 0}}`
+	if err := OutputToTemplate(os.Stdout, "procs", script, data, nil); err != nil {
+		panic(err)
+	}
+	// output:
+	// Name        Started
+	// web         2017-06-15T10:30:00Z
+	// db          2017-06-15T10:31:00Z
+}
+
+func ExampleOptTableX_fmtTag() {
+	data := []struct {
+		Name  string
+		Flags uint8 `tfortools:"fmt=%08b"`
+	}{
+		{"web", 5},
+		{"db", 12},
+	}
+
+	script := `{{tablex . 12 8 0}}`
+	if err := OutputToTemplate(os.Stdout, "procs", script, data, nil); err != nil {
+		panic(err)
+	}
+	// output:
+	// Name        Flags
+	// web         00000101
+	// db          00001100
+}
+
+func ExampleOutputToTemplateStream() {
+	data := []struct {
+		Name string
+		Age  int
+	}{
+		{"Caesar", 55},
+		{"Alexander", 32},
+		{"Nero", 30},
+	}
+
+	it, err := IteratorFromSlice(data)
+	if err != nil {
+		panic(err)
+	}
+
+	// filterHasPrefix and tablex consume it one element at a time; the
+	// full data set is never held in memory at once.
+	script := `{{tablex (filterHasPrefix . "Name" "A") 12 8 0}}`
+	if err := OutputToTemplateStream(os.Stdout, "people", script, it, nil); err != nil {
+		panic(err)
+	}
+	// output:
+	// Name        Age
+	// Alexander   32
+}
+
+func ExampleOutputToTemplateStream_aggregate() {
+	data := []struct {
+		Name string
+		Age  int
+	}{
+		{"Caesar", 55},
+		{"Alexander", 32},
+		{"Nero", 30},
+	}
+
+	it, err := IteratorFromSlice(data)
+	if err != nil {
+		panic(err)
+	}
+
+	// sum and tocsv can't process it one element at a time, so they buffer
+	// it into a slice first, via drainToSlice, subject to MaxBufferedRows.
+	script := `{{sum . "Age"}}`
+	if err := OutputToTemplateStream(os.Stdout, "people", script, it, nil); err != nil {
+		panic(err)
+	}
+	// output:
+	// 117
+}
+
+func ExampleConfig_MaxBufferedRows() {
+	data := []struct{ Name string }{{"Caesar"}, {"Alexander"}, {"Nero"}}
+
+	it, err := IteratorFromSlice(data)
+	if err != nil {
+		panic(err)
+	}
+
+	// tojson must buffer its entire input, unlike filter or tablex, so
+	// MaxBufferedRows caps how much of a large or unbounded stream it
+	// will read before giving up.
+	cfg := NewConfig(OptAllFns)
+	cfg.MaxBufferedRows = 2
+
+	err = OutputToTemplateStream(os.Stdout, "people", `{{tojson .}}`, it, cfg)
+	fmt.Println(err != nil && strings.Contains(err.Error(), ErrTooManyRows.Error()))
+	// output:
+	// true
+}
+
+func ExampleConfig_MaxOutputBytes() {
+	var buf bytes.Buffer
+	cfg := NewConfig(OptAllFns)
+	cfg.MaxOutputBytes = 5
+
+	err := OutputToTemplate(&buf, "x", `{{range .}}{{.}}{{end}}`, []string{"abcdefghij"}, cfg)
+	fmt.Println(buf.String())
+	fmt.Println(err == ErrOutputTruncated)
+	// output:
+	// abcde
+	// true
+}
+
+func ExampleConfig_MaxRangeIterations() {
+	data := []struct{ Name string }{{"a"}, {"b"}, {"c"}, {"d"}, {"e"}}
+	it, err := IteratorFromSlice(data)
+	if err != nil {
+		panic(err)
+	}
+
+	cfg := NewConfig(OptAllFns)
+	cfg.MaxRangeIterations = 3
+
+	err = OutputToTemplateStream(io.Discard, "x", `{{tojson .}}`, it, cfg)
+	fmt.Println(err != nil && strings.Contains(err.Error(), ErrTooManyRangeIterations.Error()))
+	// output:
+	// true
+}
+
+func ExampleConfig_MaxRangeIterations_slice() {
+	data := []struct{ Name string }{{"a"}, {"b"}, {"c"}, {"d"}, {"e"}}
+
+	cfg := NewConfig(OptAllFns)
+	cfg.MaxRangeIterations = 3
+
+	err := OutputToTemplate(io.Discard, "x", `{{range .}}{{.Name}}{{end}}`, data, cfg)
+	fmt.Println(err == ErrTooManyRangeIterations)
+
+	err = OutputToTemplate(io.Discard, "x", `{{tojson (cols . "Name")}}`, data, cfg)
+	fmt.Println(err != nil && strings.Contains(err.Error(), ErrTooManyRangeIterations.Error()))
+	// output:
+	// true
+	// true
+}
+
+func ExampleConfig_MaxTemplateDepth() {
+	type inner struct{ V int }
+	type mid struct{ I inner }
+	type outer struct{ M mid }
+
+	cfg := NewConfig(OptAllFns)
+	cfg.MaxTemplateDepth = 1
+
+	err := OutputToTemplate(io.Discard, "x", `{{tojson .}}`, outer{mid{inner{5}}}, cfg)
+	fmt.Println(err != nil && strings.Contains(err.Error(), ErrMaxDepthExceeded.Error()))
+	// output:
+	// true
+}
+
+func ExampleConfig_MaxTemplateDepth_decodedJSON() {
+	// Data decoded from untrusted JSON arrives as nested map[string]interface{}
+	// and []interface{} values rather than named structs; MaxTemplateDepth
+	// bounds those just as it does struct fields.
+	var data interface{} = map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": map[string]interface{}{
+				"c": 5,
+			},
+		},
+	}
+
+	cfg := NewConfig(OptAllFns)
+	cfg.MaxTemplateDepth = 1
+
+	err := OutputToTemplate(io.Discard, "x", `{{tojson .}}`, data, cfg)
+	fmt.Println(err != nil && strings.Contains(err.Error(), ErrMaxDepthExceeded.Error()))
+	// output:
+	// true
+}
+
+func ExampleConfig_MaxExecutionTime() {
+	cfg := NewConfig(OptAllFns)
+	cfg.MaxExecutionTime = 20 * time.Millisecond
+	err := cfg.AddCustomFn(func() string {
+		time.Sleep(200 * time.Millisecond)
+		return "done"
+	}, "slow", "- 'slow' sleeps, for this example only, to demonstrate MaxExecutionTime.")
+	if err != nil {
+		panic(err)
+	}
+
+	err = OutputToTemplate(io.Discard, "x", `{{slow}}`, nil, cfg)
+	fmt.Println(err == ErrTemplateTimeout)
+	// output:
+	// true
+}
+
+func ExampleOptSandboxDefaults() {
+	cfg := NewConfig(OptAllFns, OptSandboxDefaults)
+	fmt.Println(cfg.MaxExecutionTime)
+	fmt.Println(cfg.MaxOutputBytes)
+	fmt.Println(cfg.MaxRangeIterations)
+	fmt.Println(cfg.MaxTemplateDepth)
+	// output:
+	// 10s
+	// 10485760
+	// 1000000
+	// 100
+}
+
+func ExampleConfig_AddCustomSerializer() {
+	data := []struct{ Name string }{{"Caesar"}, {"Alexander"}}
+
+	cfg := NewConfig(OptAllFns)
+	err := cfg.AddCustomSerializer("tonames", func(w io.Writer, v interface{}) error {
+		_, err := fmt.Fprintf(w, "%v", v)
+		return err
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	script := `{{tonames .}}`
+	if err := OutputToTemplate(os.Stdout, "names", script, data, cfg); err != nil {
+		panic(err)
+	}
+	// output:
+	// [{Caesar} {Alexander}]
+}
@@ -0,0 +1,245 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfortools
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// fmtTagVerb returns the fmt-style verb pinned by a tfortools tag of the
+// form `tfortools:"fmt=%08b"`, if f has one.
+func fmtTagVerb(f reflect.StructField) (string, bool) {
+	_, raw := splitTag(string(f.Tag))
+	verb := strings.TrimPrefix(raw, "fmt=")
+	if verb == raw {
+		return "", false
+	}
+	return verb, true
+}
+
+func asTextMarshaler(v reflect.Value) (encoding.TextMarshaler, bool) {
+	if !v.CanInterface() {
+		return nil, false
+	}
+	if tm, ok := v.Interface().(encoding.TextMarshaler); ok {
+		return tm, true
+	}
+	if v.CanAddr() {
+		if tm, ok := v.Addr().Interface().(encoding.TextMarshaler); ok {
+			return tm, true
+		}
+	}
+	return nil, false
+}
+
+func asStringer(v reflect.Value) (fmt.Stringer, bool) {
+	if !v.CanInterface() {
+		return nil, false
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s, true
+	}
+	if v.CanAddr() {
+		if s, ok := v.Addr().Interface().(fmt.Stringer); ok {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+func asBinaryMarshaler(v reflect.Value) (encoding.BinaryMarshaler, bool) {
+	if !v.CanInterface() {
+		return nil, false
+	}
+	if bm, ok := v.Interface().(encoding.BinaryMarshaler); ok {
+		return bm, true
+	}
+	if v.CanAddr() {
+		if bm, ok := v.Addr().Interface().(encoding.BinaryMarshaler); ok {
+			return bm, true
+		}
+	}
+	return nil, false
+}
+
+// decoratedText renders v as text according to the formatting rules
+// attached to f: a pinned `tfortools:"fmt=..."` verb takes priority, then
+// encoding.TextMarshaler, then fmt.Stringer, then encoding.BinaryMarshaler
+// (rendered in hex).  It reports false if none of these apply, in which
+// case the caller should fall back to its own default rendering.
+func decoratedText(f reflect.StructField, v reflect.Value) (string, bool) {
+	if verb, ok := fmtTagVerb(f); ok {
+		return fmt.Sprintf(verb, v.Interface()), true
+	}
+	if tm, ok := asTextMarshaler(v); ok {
+		if b, err := tm.MarshalText(); err == nil {
+			return string(b), true
+		}
+	}
+	if s, ok := asStringer(v); ok {
+		return s.String(), true
+	}
+	if bm, ok := asBinaryMarshaler(v); ok {
+		if b, err := bm.MarshalBinary(); err == nil {
+			return fmt.Sprintf("%x", b), true
+		}
+	}
+	return "", false
+}
+
+// decorate walks v, a struct or a slice or array of structs, replacing any
+// field that carries pinned formatting (see decoratedText) with the string
+// it renders to.  It is used by tojson so that such fields appear in the
+// output as ordinary JSON strings rather than their native encoding.  v is
+// returned unchanged if it, or any of its fields, has no such formatting to
+// apply.
+func decorate(v reflect.Value) reflect.Value {
+	dv, _ := decorateDepth(v, 0, 0)
+	return dv
+}
+
+// recursesInto reports whether decorateDepth needs to recurse into a value
+// of kind k to finish decorating or depth-checking it, as opposed to
+// returning it unchanged straight away.
+func recursesInto(k reflect.Kind) bool {
+	switch k {
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map, reflect.Interface:
+		return true
+	default:
+		return false
+	}
+}
+
+// decorateDepth behaves like decorate, except that it gives up, returning
+// ErrMaxDepthExceeded, if it would need to recurse more than maxDepth
+// levels into v to finish.  maxDepth of zero means unlimited; depth is the
+// current recursion depth and should be 0 on the initial call.  Every kind
+// decorateDepth recurses into — including, notably, map and interface
+// values, as produced by unmarshaling untrusted JSON into interface{} —
+// counts toward maxDepth, so that data nested deeply enough to overflow the
+// stack is rejected with ErrMaxDepthExceeded well before it gets that deep,
+// rather than only the named struct fields tfortools itself declares.
+func decorateDepth(v reflect.Value, depth, maxDepth int) (reflect.Value, error) {
+	if maxDepth > 0 && depth > maxDepth {
+		return reflect.Value{}, ErrMaxDepthExceeded
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 || !recursesInto(v.Type().Elem().Kind()) {
+			return v, nil
+		}
+		elems := make([]reflect.Value, v.Len())
+		changed := false
+		for i := 0; i < v.Len(); i++ {
+			dv, err := decorateDepth(v.Index(i), depth+1, maxDepth)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			elems[i] = dv
+			if elems[i].Type() != v.Type().Elem() {
+				changed = true
+			}
+		}
+		if !changed {
+			return v, nil
+		}
+		out := reflect.MakeSlice(reflect.SliceOf(elems[0].Type()), v.Len(), v.Len())
+		for i, e := range elems {
+			out.Index(i).Set(e)
+		}
+		return out, nil
+	case reflect.Map:
+		if v.IsNil() || v.Len() == 0 || !recursesInto(v.Type().Elem().Kind()) {
+			return v, nil
+		}
+		keys := v.MapKeys()
+		vals := make([]reflect.Value, len(keys))
+		changed := false
+		for i, k := range keys {
+			dv, err := decorateDepth(v.MapIndex(k), depth+1, maxDepth)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			vals[i] = dv
+			if vals[i].Type() != v.Type().Elem() {
+				changed = true
+			}
+		}
+		if !changed {
+			return v, nil
+		}
+		out := reflect.MakeMapWithSize(reflect.MapOf(v.Type().Key(), vals[0].Type()), len(keys))
+		for i, k := range keys {
+			out.SetMapIndex(k, vals[i])
+		}
+		return out, nil
+	case reflect.Interface:
+		if v.IsNil() || !recursesInto(v.Elem().Kind()) {
+			return v, nil
+		}
+		return decorateDepth(v.Elem(), depth, maxDepth)
+	case reflect.Struct:
+		t := v.Type()
+		var fields []reflect.StructField
+		var values []reflect.Value
+		changed := false
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !validField(f) {
+				continue
+			}
+			fv := v.Field(i)
+			if text, ok := decoratedText(f, fv); ok {
+				otherTags, _ := splitTag(string(f.Tag))
+				fields = append(fields, reflect.StructField{
+					Name: f.Name,
+					Type: reflect.TypeOf(""),
+					Tag:  reflect.StructTag(strings.Trim(otherTags, "`")),
+				})
+				values = append(values, reflect.ValueOf(text))
+				changed = true
+				continue
+			}
+			if recursesInto(fv.Kind()) {
+				dv, err := decorateDepth(fv, depth+1, maxDepth)
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				if dv.Type() != f.Type {
+					changed = true
+				}
+				fields = append(fields, reflect.StructField{Name: f.Name, Type: dv.Type(), Tag: f.Tag})
+				values = append(values, dv)
+				continue
+			}
+			fields = append(fields, f)
+			values = append(values, fv)
+		}
+		if !changed {
+			return v, nil
+		}
+		newType := reflect.StructOf(fields)
+		out := reflect.New(newType).Elem()
+		for i, val := range values {
+			out.Field(i).Set(val)
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
@@ -0,0 +1,480 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfortools
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	stdsort "sort"
+	"strings"
+)
+
+// fieldByName locates a struct field by name, checking that it is present
+// and visible to tfortools before returning its value.
+func fieldByName(v reflect.Value, field string) (reflect.Value, error) {
+	fv := v.FieldByName(field)
+	if !fv.IsValid() {
+		return reflect.Value{}, fmt.Errorf("tfortools: no such field %s", field)
+	}
+	return fv, nil
+}
+
+// sliceValue checks that data is a slice or array, returning it as a
+// reflect.Value for the caller to index into.  A *stream is drained into a
+// slice first, via drainIfStream, so that blocking functions such as sum or
+// groupby, which consume their whole argument in one call, work the same
+// way whether data came from OutputToTemplate or OutputToTemplateStream.
+func sliceValue(data interface{}, fn string) (reflect.Value, error) {
+	data, err := drainIfStream(data)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return reflect.Value{}, fmt.Errorf("tfortools: %s requires a slice or array, got %s", fn, v.Kind())
+	}
+	return v, nil
+}
+
+// cols returns a new slice of structs, derived from data, each element of
+// which contains only the named fields.
+func cols(data interface{}, fields ...string) (interface{}, error) {
+	if s, ok := data.(*stream); ok {
+		if len(fields) == 0 {
+			return newStream(&sliceIterator{v: reflect.ValueOf([]struct{}{})}, s.maxBuffered), nil
+		}
+		return newStream(&colsIterator{src: s.it, fields: fields}, s.maxBuffered), nil
+	}
+
+	v, err := sliceValue(data, "cols")
+	if err != nil {
+		return nil, err
+	}
+
+	if v.Len() == 0 || len(fields) == 0 {
+		return []struct{}{}, nil
+	}
+
+	elemType := v.Index(0).Type()
+	sfs := make([]reflect.StructField, len(fields))
+	for i, field := range fields {
+		f, ok := elemType.FieldByName(field)
+		if !ok {
+			return nil, fmt.Errorf("tfortools: cols: no such field %s", field)
+		}
+		sfs[i] = f
+	}
+	newType := reflect.StructOf(sfs)
+
+	result := reflect.MakeSlice(reflect.SliceOf(newType), v.Len(), v.Len())
+	for i := 0; i < v.Len(); i++ {
+		out := result.Index(i)
+		in := v.Index(i)
+		for _, field := range fields {
+			out.FieldByName(field).Set(in.FieldByName(field))
+		}
+	}
+	return result.Interface(), nil
+}
+
+// rows returns a new slice containing only the elements of data found at
+// the given indices, preserving the order in which the indices are
+// specified.
+func rows(data interface{}, indices ...int) (interface{}, error) {
+	v, err := sliceValue(data, "rows")
+	if err != nil {
+		return nil, err
+	}
+
+	result := reflect.MakeSlice(v.Type(), 0, len(indices))
+	for _, i := range indices {
+		if i < 0 || i >= v.Len() {
+			return nil, fmt.Errorf("tfortools: rows: index %d out of range", i)
+		}
+		result = reflect.Append(result, v.Index(i))
+	}
+	return result.Interface(), nil
+}
+
+// head returns a new slice containing, at most, the first element of data.
+// When data is a stream, head reads only that one element, leaving the rest
+// of the stream unconsumed.
+func head(data interface{}) (interface{}, error) {
+	if s, ok := data.(*stream); ok {
+		v, ok, err := s.it.Next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return []struct{}{}, nil
+		}
+		return sliceof(v), nil
+	}
+
+	v, err := sliceValue(data, "head")
+	if err != nil {
+		return nil, err
+	}
+	if v.Len() == 0 {
+		return v.Interface(), nil
+	}
+	return v.Slice(0, 1).Interface(), nil
+}
+
+// tail returns a new slice containing, at most, the last n elements of
+// data (one element if n is omitted), preserving their original order.
+// When data is a stream, tail reads it to exhaustion, keeping only the
+// trailing n elements in memory at any one time.
+func tail(data interface{}, n ...int) (interface{}, error) {
+	count := 1
+	if len(n) > 0 {
+		count = n[0]
+	}
+	if count < 0 {
+		count = 0
+	}
+
+	if s, ok := data.(*stream); ok {
+		return tailStream(s, count)
+	}
+
+	v, err := sliceValue(data, "tail")
+	if err != nil {
+		return nil, err
+	}
+	if count > v.Len() {
+		count = v.Len()
+	}
+	return v.Slice(v.Len()-count, v.Len()).Interface(), nil
+}
+
+// tailStream reads s to exhaustion, keeping a ring buffer of the most
+// recent n elements.
+func tailStream(s *stream, n int) (interface{}, error) {
+	if n == 0 {
+		for {
+			_, ok, err := s.it.Next()
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				break
+			}
+		}
+		return []struct{}{}, nil
+	}
+
+	buf := make([]interface{}, 0, n)
+	next := 0
+	for {
+		v, ok, err := s.it.Next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		if len(buf) < n {
+			buf = append(buf, v)
+		} else {
+			buf[next] = v
+			next = (next + 1) % n
+		}
+	}
+	if len(buf) == 0 {
+		return []struct{}{}, nil
+	}
+
+	ordered := make([]interface{}, len(buf))
+	for i := range buf {
+		ordered[i] = buf[(next+i)%len(buf)]
+	}
+	elemType := reflect.TypeOf(ordered[0])
+	result := reflect.MakeSlice(reflect.SliceOf(elemType), len(ordered), len(ordered))
+	for i, v := range ordered {
+		result.Index(i).Set(reflect.ValueOf(v))
+	}
+	return result.Interface(), nil
+}
+
+type matcher func(fieldText, value string) bool
+
+func filterBy(data interface{}, field, value string, match matcher) (interface{}, error) {
+	if s, ok := data.(*stream); ok {
+		return newStream(&filterIterator{src: s.it, field: field, value: value, match: match}, s.maxBuffered), nil
+	}
+
+	v, err := sliceValue(data, "filter")
+	if err != nil {
+		return nil, err
+	}
+
+	result := reflect.MakeSlice(v.Type(), 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		fv, err := fieldByName(v.Index(i), field)
+		if err != nil {
+			return nil, err
+		}
+		if match(fmt.Sprint(fv.Interface()), value) {
+			result = reflect.Append(result, v.Index(i))
+		}
+	}
+	return result.Interface(), nil
+}
+
+func filter(data interface{}, field, value string) (interface{}, error) {
+	return filterBy(data, field, value, func(text, val string) bool { return text == val })
+}
+
+func filterContains(data interface{}, field, value string) (interface{}, error) {
+	return filterBy(data, field, value, strings.Contains)
+}
+
+func filterHasPrefix(data interface{}, field, value string) (interface{}, error) {
+	return filterBy(data, field, value, strings.HasPrefix)
+}
+
+func filterHasSuffix(data interface{}, field, value string) (interface{}, error) {
+	return filterBy(data, field, value, strings.HasSuffix)
+}
+
+func filterFolded(data interface{}, field, value string) (interface{}, error) {
+	return filterBy(data, field, value, strings.EqualFold)
+}
+
+func filterRegexp(data interface{}, field, pattern string) (interface{}, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("tfortools: filterRegexp: %v", err)
+	}
+	return filterBy(data, field, pattern, func(text, _ string) bool { return re.MatchString(text) })
+}
+
+// less compares two reflect.Values representing the same field of two
+// elements of the slice being sorted.
+func less(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() < b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() < b.Float()
+	case reflect.String:
+		return a.String() < b.String()
+	default:
+		return fmt.Sprint(a.Interface()) < fmt.Sprint(b.Interface())
+	}
+}
+
+// sortFn returns a new slice containing the elements of data, sorted in
+// ascending order of field.  It is installed in the funcMap under the name
+// "sort".
+func sortFn(data interface{}, field string) (interface{}, error) {
+	v, err := sliceValue(data, "sort")
+	if err != nil {
+		return nil, err
+	}
+
+	result := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+	reflect.Copy(result, v)
+
+	var sortErr error
+	stdsort.SliceStable(result.Interface(), func(i, j int) bool {
+		a, err := fieldByName(result.Index(i), field)
+		if err != nil {
+			sortErr = err
+			return false
+		}
+		b, err := fieldByName(result.Index(j), field)
+		if err != nil {
+			sortErr = err
+			return false
+		}
+		return less(a, b)
+	})
+	if sortErr != nil {
+		return nil, sortErr
+	}
+	return result.Interface(), nil
+}
+
+// describe returns a Go-like description of the type of data.  It is the
+// template-function equivalent of GenerateUsageUndecorated.
+func describe(data interface{}) string {
+	return GenerateUsageUndecorated(data)
+}
+
+// promote walks path, a dotted sequence of field names such as
+// "User.Credentials", through each element of data and returns a new slice
+// containing the values found at the end of that path.
+func promote(data interface{}, path string) (interface{}, error) {
+	v, err := sliceValue(data, "promote")
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.Split(path, ".")
+	if v.Len() == 0 {
+		return []struct{}{}, nil
+	}
+
+	leafType, err := func() (reflect.Type, error) {
+		t := v.Index(0).Type()
+		for _, f := range fields {
+			sf, ok := t.FieldByName(f)
+			if !ok {
+				return nil, fmt.Errorf("tfortools: promote: no such field %s", f)
+			}
+			t = sf.Type
+		}
+		return t, nil
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	result := reflect.MakeSlice(reflect.SliceOf(leafType), 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		fv := v.Index(i)
+		for _, f := range fields {
+			fv = fv.FieldByName(f)
+		}
+		result = reflect.Append(result, fv)
+	}
+	return result.Interface(), nil
+}
+
+// sliceof wraps a single value in a new, one element, slice.  It is useful
+// for feeding scalar data into helpers, such as tablex, that expect a
+// slice or array.
+func sliceof(data interface{}) interface{} {
+	t := reflect.TypeOf(data)
+	result := reflect.MakeSlice(reflect.SliceOf(t), 1, 1)
+	result.Index(0).Set(reflect.ValueOf(data))
+	return result.Interface()
+}
+
+// table is the type returned by totable.  It lets users treat a
+// [][]string, whose first row is a header row, in much the same way as a
+// slice of structs.
+type table struct {
+	Header []string
+	Rows   [][]string
+}
+
+func totable(data [][]string) (*table, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("tfortools: totable: data must contain at least a header row")
+	}
+	return &table{Header: data[0], Rows: data[1:]}, nil
+}
+
+func selectColumn(t *table, field string) (string, error) {
+	idx := -1
+	for i, h := range t.Header {
+		if h == field {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return "", fmt.Errorf("tfortools: select: no such column %s", field)
+	}
+	values := make([]string, len(t.Rows))
+	for i, row := range t.Rows {
+		values[i] = row[idx]
+	}
+	return strings.Join(values, "\n") + "\n", nil
+}
+
+func selectField(data interface{}, field string, alt bool) (string, error) {
+	if t, ok := data.(*table); ok {
+		return selectColumn(t, field)
+	}
+	if s, ok := data.(*stream); ok {
+		return selectStream(s, field, alt)
+	}
+
+	v, err := sliceValue(data, "select")
+	if err != nil {
+		return "", err
+	}
+	if v.Len() == 0 {
+		return "\n", nil
+	}
+	sf, ok := v.Type().Elem().FieldByName(field)
+	if !ok {
+		return "", fmt.Errorf("tfortools: no such field %s", field)
+	}
+	values := make([]string, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		fv, err := fieldByName(v.Index(i), field)
+		if err != nil {
+			return "", err
+		}
+		values[i] = formatCell(sf, fv, alt)
+	}
+	return strings.Join(values, "\n") + "\n", nil
+}
+
+// selectStream extracts field from every element yielded by s, one at a
+// time, returning the results joined by newlines.  Unlike the slice based
+// path, it never holds more than one element of s in memory at once.
+func selectStream(s *stream, field string, alt bool) (string, error) {
+	var b strings.Builder
+	var sf reflect.StructField
+	haveField := false
+	for {
+		v, ok, err := s.it.Next()
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			break
+		}
+		rv := reflect.ValueOf(v)
+		if !haveField {
+			f, ok := rv.Type().FieldByName(field)
+			if !ok {
+				return "", fmt.Errorf("tfortools: no such field %s", field)
+			}
+			sf = f
+			haveField = true
+		}
+		fv, err := fieldByName(rv, field)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(formatCell(sf, fv, alt))
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+// selectFn extracts field from every element of data, returning the
+// results joined by newlines.  data may either be a slice of structs or a
+// *table, as returned by totable.  It is installed in the funcMap under
+// the name "select".
+func selectFn(data interface{}, field string) (string, error) {
+	return selectField(data, field, false)
+}
+
+// selectalt behaves like select but renders each value using the same
+// alternate formatting rules as tablexalt and htablexalt.
+func selectalt(data interface{}, field string) (string, error) {
+	return selectField(data, field, true)
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfortools
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Iterator supplies the elements of a dataset to OutputToTemplateStream one
+// at a time, so that large datasets can be processed without first being
+// loaded, in their entirety, into memory.
+type Iterator interface {
+	// Next returns the next element.  ok is false once the Iterator is
+	// exhausted, at which point value and err are ignored.  A non-nil
+	// err stops iteration immediately, and is propagated to the caller
+	// of OutputToTemplateStream.
+	Next() (value interface{}, ok bool, err error)
+}
+
+// sliceIterator adapts a slice or array, accessed via reflection, to the
+// Iterator interface.
+type sliceIterator struct {
+	v reflect.Value
+	i int
+}
+
+func (it *sliceIterator) Next() (interface{}, bool, error) {
+	if it.i >= it.v.Len() {
+		return nil, false, nil
+	}
+	value := it.v.Index(it.i).Interface()
+	it.i++
+	return value, true, nil
+}
+
+// IteratorFromSlice returns an Iterator that yields the elements of data, a
+// slice or array, in order.
+func IteratorFromSlice(data interface{}) (Iterator, error) {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("tfortools: IteratorFromSlice requires a slice or array, got %s", v.Kind())
+	}
+	return &sliceIterator{v: v}, nil
+}
+
+// chanIterator adapts a channel, accessed via reflection, to the Iterator
+// interface.
+type chanIterator struct {
+	ch reflect.Value
+}
+
+func (it *chanIterator) Next() (interface{}, bool, error) {
+	v, ok := it.ch.Recv()
+	if !ok {
+		return nil, false, nil
+	}
+	return v.Interface(), true, nil
+}
+
+// IteratorFromChan returns an Iterator that yields the values received from
+// ch until it is closed.  ch must be a channel.
+func IteratorFromChan(ch interface{}) (Iterator, error) {
+	v := reflect.ValueOf(ch)
+	if v.Kind() != reflect.Chan {
+		return nil, fmt.Errorf("tfortools: IteratorFromChan requires a channel, got %s", v.Kind())
+	}
+	return &chanIterator{ch: v}, nil
+}
+
+// jsonDecoderIterator adapts a json.Decoder, positioned at the start of a
+// JSON array, to the Iterator interface, decoding one array element at a
+// time.
+type jsonDecoderIterator struct {
+	dec      *json.Decoder
+	elemType reflect.Type
+}
+
+func (it *jsonDecoderIterator) Next() (interface{}, bool, error) {
+	if !it.dec.More() {
+		return nil, false, nil
+	}
+	ev := reflect.New(it.elemType)
+	if err := it.dec.Decode(ev.Interface()); err != nil {
+		return nil, false, fmt.Errorf("tfortools: IteratorFromJSONDecoder: %v", err)
+	}
+	return ev.Elem().Interface(), true, nil
+}
+
+// IteratorFromJSONDecoder returns an Iterator that decodes successive JSON
+// array elements from dec, each into a new value of the type of elem, a
+// zero value of the element type (e.g. MyStruct{}).  dec must be
+// positioned just before the array's opening '['; IteratorFromJSONDecoder
+// consumes that token before returning.
+func IteratorFromJSONDecoder(dec *json.Decoder, elem interface{}) (Iterator, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("tfortools: IteratorFromJSONDecoder: %v", err)
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '[' {
+		return nil, fmt.Errorf("tfortools: IteratorFromJSONDecoder: expected '[', got %v", tok)
+	}
+	return &jsonDecoderIterator{dec: dec, elemType: reflect.TypeOf(elem)}, nil
+}
@@ -0,0 +1,362 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfortools
+
+const colsHelp = `- 'cols' can be used to extract certain columns from a table consisting of a
+  slice or array of structs.  It returns a new slice of structs which contain
+  only the fields requested by the caller.   For example, given a slice of structs
+
+  {{cols . "Name" "Address"}}
+
+  returns a new slice of structs, each element of which is a structure with only
+  two fields, 'Name' and 'Address'.`
+
+const rowsHelp = `- 'rows' selects a subset of rows from a slice or array.  It returns a new
+  slice containing only the rows whose indices are passed to it.  For example
+
+  {{rows . 0 2}}
+
+  returns a new slice containing the first and third elements of the data.`
+
+const headHelp = `- 'head' returns a new slice or array containing, at most, the first element
+  of its input.  For example
+
+  {{head .}}`
+
+const tailHelp = `- 'tail' returns a new slice or array containing, at most, the last element
+  of its input, or the last n elements if called with an extra argument, n.
+  For example
+
+  {{tail .}}
+  {{tail . 5}}`
+
+const filterHelp = `- 'filter' creates a new slice of structs which contain all the elements
+  from the initial slice whose chosen field matches a given value.  For
+  example
+
+  {{filter . "Name" "John Smith"}}
+
+  returns a new slice containing only the elements whose 'Name' field is
+  'John Smith'.`
+
+const filterContainsHelp = `- 'filterContains' is similar to 'filter' except it matches when the
+  chosen field contains the given value, e.g.,
+
+  {{filterContains . "Name" "Smith"}}`
+
+const filterHasPrefixHelp = `- 'filterHasPrefix' is similar to 'filter' except it matches when the
+  chosen field starts with the given value, e.g.,
+
+  {{filterHasPrefix . "Name" "John"}}`
+
+const filterHasSuffixHelp = `- 'filterHasSuffix' is similar to 'filter' except it matches when the
+  chosen field ends with the given value, e.g.,
+
+  {{filterHasSuffix . "Name" "Smith"}}`
+
+const filterFoldedHelp = `- 'filterFolded' is similar to 'filter' except the comparison ignores the
+  case of the chosen field, e.g.,
+
+  {{filterFolded . "Name" "john smith"}}`
+
+const filterRegexpHelp = `- 'filterRegexp' is similar to 'filter' except it matches when the chosen
+  field matches the given regular expression, e.g.,
+
+  {{filterRegexp . "Name" "^John"}}`
+
+const sortHelp = `- 'sort' sorts a slice or array of structs into ascending order, based on
+  the value of the named field, e.g.,
+
+  {{sort . "Name"}}`
+
+const describeHelp = `- 'describe' returns a Go-like description of the type of its argument.
+  It's mostly useful for debugging templates, e.g.,
+
+  {{describe .}}`
+
+const promoteHelp = `- 'promote' extracts a nested field from every element of a slice of
+  structs and returns a new slice containing just that field, e.g., given a
+  slice of structs each of which contains a field called 'User' of type
+  struct { Name string },
+
+  {{promote . "User.Name"}}
+
+  returns a new []string containing the Name of every User.`
+
+const sliceofHelp = `- 'sliceof' wraps its argument in a new, one element, slice.  This is
+  useful for passing a single value to functions, such as 'tablex', that
+  expect a slice, e.g.,
+
+  {{sliceof .}}`
+
+const totableHelp = `- 'totable' converts a [][]string, whose first row is treated as a header
+  row, into a table that can be queried with 'select', e.g.,
+
+  {{with (totable .)}}{{select . "Name"}}{{end}}`
+
+const selectHelp = `- 'select' extracts the named field from every element of a slice of
+  structs, or the named column from a table created with 'totable', and
+  returns the results joined by newlines.  Fields are rendered using the
+  same rules as 'tablex', e.g.,
+
+  {{select . "Name"}}`
+
+const selectaltHelp = `- 'selectalt' behaves like 'select' except that it renders each value
+  using the same alternate formatting rules as 'tablexalt', e.g.,
+
+  {{selectalt . "Mask"}}`
+
+const tojsonHelp = `- 'tojson' renders its argument as indented JSON.  It gives up with an
+  error if Config.MaxTemplateDepth is set and rendering would recurse
+  deeper than that, e.g.,
+
+  {{tojson .}}`
+
+const tablexHelp = `- 'tablex' pretty prints a slice or array of structs as a table.  It takes
+  three extra arguments, minwidth, tabwidth and padding, which are passed
+  directly to a text/tabwriter.Writer.  A field whose type implements
+  encoding.TextMarshaler, fmt.Stringer or encoding.BinaryMarshaler is
+  rendered using that method, and a field tagged tfortools:"fmt=<verb>"
+  is rendered with that fmt verb instead, e.g.,
+
+  {{tablex . 12 8 0}}`
+
+const tablexaltHelp = `- 'tablexalt' behaves like 'tablex' except that it renders each cell using
+  the same alternate formatting rules as 'selectalt', e.g.,
+
+  {{tablexalt . 12 8 0}}`
+
+const htablexHelp = `- 'htablex' pretty prints a slice or array of structs as a series of
+  vertical, field-per-line, tables, one per element, e.g.,
+
+  {{htablex . 12 8 0}}`
+
+const htablexaltHelp = `- 'htablexalt' behaves like 'htablex' except that it renders each value
+  using the same alternate formatting rules as 'selectalt', e.g.,
+
+  {{htablexalt . 12 8 0}}`
+
+const groupbyHelp = `- 'groupby' groups the elements of a slice or array of structs by the
+  value of one of their fields.  It returns a new slice of
+  struct{ Key interface{}; Values []T }, one element for each distinct
+  value of the field, which can itself be ranged over or passed to other
+  functions such as 'sort', 'head' and 'tablex', e.g.,
+
+  {{range (groupby . "FirstName")}}{{.Key}}: {{len .Values}}{{println}}{{end}}`
+
+const countHelp = `- 'count' returns the number of elements in a slice or array, e.g.,
+
+  {{count .}}`
+
+const sumHelp = `- 'sum' walks a numeric field across every element of a slice or array of
+  structs and returns its sum, e.g.,
+
+  {{sum . "Amount"}}`
+
+const avgHelp = `- 'avg' behaves like 'sum' except that it returns the mean, as a float64,
+  of the named field, e.g.,
+
+  {{avg . "Amount"}}`
+
+const minHelp = `- 'min' returns the smallest value of a numeric or string field found
+  across every element of a slice or array of structs, e.g.,
+
+  {{min . "Amount"}}`
+
+const maxHelp = `- 'max' behaves like 'min' except that it returns the largest value of
+  the named field, e.g.,
+
+  {{max . "Amount"}}`
+
+const distinctHelp = `- 'distinct' returns a new slice containing the distinct values of a
+  field found across every element of a slice or array of structs, in the
+  order in which they first appear, e.g.,
+
+  {{distinct . "FirstName"}}`
+
+const toyamlHelp = `- 'toyaml' renders its argument as YAML, e.g.,
+
+  {{toyaml .}}`
+
+const totomlHelp = `- 'totoml' renders its argument as TOML, e.g.,
+
+  {{totoml .}}`
+
+const tocsvHelp = `- 'tocsv' renders data, a slice or array of structs, as a comma
+  separated table.  The header row is derived from the exported field
+  names of the struct, or from a field tagged tfortools:"title=<name>",
+  if present, and fields are rendered using the same rules as 'tablex',
+  e.g.,
+
+  {{tocsv .}}`
+
+const totsvHelp = `- 'totsv' behaves exactly like 'tocsv' except that it separates fields
+  with tabs rather than commas, e.g.,
+
+  {{totsv .}}`
+
+const toxmlHelp = `- 'toxml' renders its argument as XML, e.g.,
+
+  {{toxml .}}`
+
+// OptCols enables the 'cols' template function.
+func OptCols(cfg *Config) { cfg.addFn("cols", colsHelp, limitRange(cfg, cols)) }
+
+// OptRows enables the 'rows' template function.
+func OptRows(cfg *Config) { cfg.addFn("rows", rowsHelp, limitRange(cfg, rows)) }
+
+// OptHead enables the 'head' template function.
+func OptHead(cfg *Config) { cfg.addFn("head", headHelp, limitRange(cfg, head)) }
+
+// OptTail enables the 'tail' template function.
+func OptTail(cfg *Config) { cfg.addFn("tail", tailHelp, limitRange(cfg, tail)) }
+
+// OptFilter enables the 'filter' template function.
+func OptFilter(cfg *Config) { cfg.addFn("filter", filterHelp, limitRange(cfg, filter)) }
+
+// OptFilterContains enables the 'filterContains' template function.
+func OptFilterContains(cfg *Config) {
+	cfg.addFn("filterContains", filterContainsHelp, limitRange(cfg, filterContains))
+}
+
+// OptFilterHasPrefix enables the 'filterHasPrefix' template function.
+func OptFilterHasPrefix(cfg *Config) {
+	cfg.addFn("filterHasPrefix", filterHasPrefixHelp, limitRange(cfg, filterHasPrefix))
+}
+
+// OptFilterHasSuffix enables the 'filterHasSuffix' template function.
+func OptFilterHasSuffix(cfg *Config) {
+	cfg.addFn("filterHasSuffix", filterHasSuffixHelp, limitRange(cfg, filterHasSuffix))
+}
+
+// OptFilterFolded enables the 'filterFolded' template function.
+func OptFilterFolded(cfg *Config) {
+	cfg.addFn("filterFolded", filterFoldedHelp, limitRange(cfg, filterFolded))
+}
+
+// OptFilterRegexp enables the 'filterRegexp' template function.
+func OptFilterRegexp(cfg *Config) {
+	cfg.addFn("filterRegexp", filterRegexpHelp, limitRange(cfg, filterRegexp))
+}
+
+// OptSort enables the 'sort' template function.
+func OptSort(cfg *Config) { cfg.addFn("sort", sortHelp, limitRange(cfg, sortFn)) }
+
+// OptDescribe enables the 'describe' template function.
+func OptDescribe(cfg *Config) { cfg.addFn("describe", describeHelp, describe) }
+
+// OptPromote enables the 'promote' template function.
+func OptPromote(cfg *Config) { cfg.addFn("promote", promoteHelp, limitRange(cfg, promote)) }
+
+// OptSliceof enables the 'sliceof' template function.
+func OptSliceof(cfg *Config) { cfg.addFn("sliceof", sliceofHelp, sliceof) }
+
+// OptToTable enables the 'totable' template function.
+func OptToTable(cfg *Config) { cfg.addFn("totable", totableHelp, totable) }
+
+// OptGroupBy enables the 'groupby' template function.
+func OptGroupBy(cfg *Config) { cfg.addFn("groupby", groupbyHelp, limitRange(cfg, groupby)) }
+
+// OptCount enables the 'count' template function.
+func OptCount(cfg *Config) { cfg.addFn("count", countHelp, limitRange(cfg, count)) }
+
+// OptSum enables the 'sum' template function.
+func OptSum(cfg *Config) { cfg.addFn("sum", sumHelp, limitRange(cfg, sum)) }
+
+// OptAvg enables the 'avg' template function.
+func OptAvg(cfg *Config) { cfg.addFn("avg", avgHelp, limitRange(cfg, avg)) }
+
+// OptMin enables the 'min' template function.
+func OptMin(cfg *Config) { cfg.addFn("min", minHelp, limitRange(cfg, minFn)) }
+
+// OptMax enables the 'max' template function.
+func OptMax(cfg *Config) { cfg.addFn("max", maxHelp, limitRange(cfg, maxFn)) }
+
+// OptDistinct enables the 'distinct' template function.
+func OptDistinct(cfg *Config) { cfg.addFn("distinct", distinctHelp, limitRange(cfg, distinct)) }
+
+// OptToYAML enables the 'toyaml' template function.
+func OptToYAML(cfg *Config) {
+	cfg.addFn("toyaml", toyamlHelp, limitRange(cfg, wrapSerializer(toYAMLWriter)))
+}
+
+// OptToTOML enables the 'totoml' template function.
+func OptToTOML(cfg *Config) {
+	cfg.addFn("totoml", totomlHelp, limitRange(cfg, wrapSerializer(toTOMLWriter)))
+}
+
+// OptToCSV enables the 'tocsv' template function.
+func OptToCSV(cfg *Config) {
+	cfg.addFn("tocsv", tocsvHelp, limitRange(cfg, wrapSerializer(toCSVWriter)))
+}
+
+// OptToTSV enables the 'totsv' template function.
+func OptToTSV(cfg *Config) {
+	cfg.addFn("totsv", totsvHelp, limitRange(cfg, wrapSerializer(toTSVWriter)))
+}
+
+// OptToXML enables the 'toxml' template function.
+func OptToXML(cfg *Config) {
+	cfg.addFn("toxml", toxmlHelp, limitRange(cfg, wrapSerializer(toXMLWriter)))
+}
+
+// OptSelectAlt enables the 'selectalt' template function.
+func OptSelectAlt(cfg *Config) { cfg.addFn("selectalt", selectaltHelp, limitRange(cfg, selectalt)) }
+
+// OptToJSON enables the 'tojson' template function.  If cfg.MaxTemplateDepth
+// is set, it is honored even if it is changed after OptToJSON has run.
+func OptToJSON(cfg *Config) {
+	cfg.addFn("tojson", tojsonHelp, limitRange(cfg, func(data interface{}) (string, error) {
+		return tojsonDepth(data, cfg.MaxTemplateDepth)
+	}))
+}
+
+// OptTableX enables the 'tablex' template function.
+func OptTableX(cfg *Config) { cfg.addFn("tablex", tablexHelp, limitRange(cfg, tablex)) }
+
+// OptTableXAlt enables the 'tablexalt' template function.
+func OptTableXAlt(cfg *Config) { cfg.addFn("tablexalt", tablexaltHelp, limitRange(cfg, tablexalt)) }
+
+// OptHTableX enables the 'htablex' template function.
+func OptHTableX(cfg *Config) { cfg.addFn("htablex", htablexHelp, limitRange(cfg, htablex)) }
+
+// OptHTableXAlt enables the 'htablexalt' template function.
+func OptHTableXAlt(cfg *Config) {
+	cfg.addFn("htablexalt", htablexaltHelp, limitRange(cfg, htablexalt))
+}
+
+// OptSelect enables the 'select' template function.
+func OptSelect(cfg *Config) { cfg.addFn("select", selectHelp, limitRange(cfg, selectFn)) }
+
+// OptAllFns enables every template function that tfortools provides. This
+// reserves their names against later registration: a caller that also
+// calls AddCustomFn or AddCustomSerializer with one of those names,
+// including the aggregate functions count, sum, avg, min, max and
+// distinct, gets an error back from that call rather than having its
+// function silently replace, or be replaced by, the one OptAllFns adds.
+func OptAllFns(cfg *Config) {
+	for _, opt := range []Option{
+		OptCols, OptRows, OptHead, OptTail,
+		OptFilter, OptFilterContains, OptFilterHasPrefix, OptFilterHasSuffix,
+		OptFilterFolded, OptFilterRegexp,
+		OptSort, OptDescribe, OptPromote, OptSliceof,
+		OptToTable, OptSelect, OptSelectAlt, OptToJSON,
+		OptTableX, OptTableXAlt, OptHTableX, OptHTableXAlt,
+		OptGroupBy, OptCount, OptSum, OptAvg, OptMin, OptMax, OptDistinct,
+		OptToYAML, OptToTOML, OptToCSV, OptToTSV, OptToXML,
+	} {
+		opt(cfg)
+	}
+}
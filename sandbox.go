@@ -0,0 +1,147 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfortools
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"sync/atomic"
+	"time"
+)
+
+// ErrTemplateTimeout is returned by OutputToTemplate and
+// OutputToTemplateContext when executing script takes longer than
+// Config.MaxExecutionTime.
+var ErrTemplateTimeout = errors.New("tfortools: template execution timed out")
+
+// ErrOutputTruncated is returned when script produces more than
+// Config.MaxOutputBytes of output.  Whatever was written before the limit
+// was reached has already been copied to the destination io.Writer.
+var ErrOutputTruncated = errors.New("tfortools: template output exceeded MaxOutputBytes")
+
+// ErrMaxDepthExceeded is returned by tojson when rendering data requires
+// recursing more than Config.MaxTemplateDepth levels deep into its fields.
+var ErrMaxDepthExceeded = errors.New("tfortools: exceeded MaxTemplateDepth")
+
+// ErrTooManyRangeIterations is returned by OutputToTemplateStream when an
+// Iterator yields more than Config.MaxRangeIterations elements during a
+// single template execution.
+var ErrTooManyRangeIterations = errors.New("tfortools: exceeded MaxRangeIterations")
+
+// OptSandboxDefaults applies a conservative set of sandbox limits to cfg,
+// suitable for executing a template script of unknown provenance: a ten
+// second execution timeout, a ten megabyte output limit, a cap of one
+// million range iterations, and a template depth of 100.  Callers with
+// more specific requirements should set the relevant Config fields
+// directly instead.
+//
+// The execution timeout bounds latency only, not resource consumption: see
+// Config.MaxExecutionTime and OutputToTemplateContext for what a script
+// that hits it goes on doing after OutputToTemplate has returned.
+func OptSandboxDefaults(cfg *Config) {
+	cfg.MaxExecutionTime = 10 * time.Second
+	cfg.MaxOutputBytes = 10 * 1024 * 1024
+	cfg.MaxRangeIterations = 1000000
+	cfg.MaxTemplateDepth = 100
+}
+
+// limitedWriter wraps an io.Writer, returning ErrOutputTruncated once more
+// than max bytes have been written to it.  max of zero means unlimited.
+type limitedWriter struct {
+	w       io.Writer
+	max     int64
+	written int64
+}
+
+func (lw *limitedWriter) Write(p []byte) (int, error) {
+	if lw.max <= 0 {
+		return lw.w.Write(p)
+	}
+	if lw.written >= lw.max {
+		return 0, ErrOutputTruncated
+	}
+	if lw.written+int64(len(p)) <= lw.max {
+		n, err := lw.w.Write(p)
+		lw.written += int64(n)
+		return n, err
+	}
+	allowed := lw.max - lw.written
+	n, err := lw.w.Write(p[:allowed])
+	lw.written += int64(n)
+	if err != nil {
+		return n, err
+	}
+	return n, ErrOutputTruncated
+}
+
+// countingIterator wraps another Iterator, returning
+// ErrTooManyRangeIterations once more than max elements have been read from
+// it.  max of zero means unlimited.
+type countingIterator struct {
+	src   Iterator
+	max   int
+	count int64
+}
+
+func (it *countingIterator) Next() (interface{}, bool, error) {
+	if it.max > 0 && atomic.AddInt64(&it.count, 1) > int64(it.max) {
+		return nil, false, ErrTooManyRangeIterations
+	}
+	return it.src.Next()
+}
+
+// limitRange wraps fn, a template function whose first parameter is the
+// slice or array of data it consumes in its entirety, so that it also
+// honors cfg.MaxRangeIterations: if data is longer than the limit,
+// ErrTooManyRangeIterations is returned in fn's place, without fn itself
+// being called.  It gives the ordinary, slice-based form of
+// OutputToTemplate the same protection that countingIterator gives
+// OutputToTemplateStream, whose Iterator, unlike a slice, has no upfront
+// length to check.  Like MaxBufferedRows, the limit applies per call, not
+// cumulatively across an execution that reads the same data more than
+// once.
+func limitRange(cfg *Config, fn interface{}) interface{} {
+	fnVal := reflect.ValueOf(fn)
+	fnType := fnVal.Type()
+	return reflect.MakeFunc(fnType, func(args []reflect.Value) []reflect.Value {
+		if cfg.MaxRangeIterations > 0 && len(args) > 0 {
+			data := args[0]
+			if data.Kind() == reflect.Interface {
+				data = data.Elem()
+			}
+			if (data.Kind() == reflect.Slice || data.Kind() == reflect.Array) && data.Len() > cfg.MaxRangeIterations {
+				return errResults(fnType, ErrTooManyRangeIterations)
+			}
+		}
+		if fnType.IsVariadic() {
+			return fnVal.CallSlice(args)
+		}
+		return fnVal.Call(args)
+	}).Interface()
+}
+
+// errResults builds the zero valued return values for a function of type
+// fnType whose final result is an error, with that result set to err.  It
+// is used by limitRange to short circuit a wrapped function without
+// calling it.
+func errResults(fnType reflect.Type, err error) []reflect.Value {
+	out := make([]reflect.Value, fnType.NumOut())
+	for i := 0; i < fnType.NumOut()-1; i++ {
+		out[i] = reflect.Zero(fnType.Out(i))
+	}
+	out[fnType.NumOut()-1] = reflect.ValueOf(err)
+	return out
+}
@@ -0,0 +1,357 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfortools
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// titleTagValue returns the column title pinned by a tfortools tag of the
+// form `tfortools:"title=Foo"`, if f has one.  This is a dedicated
+// sub-syntax, distinct from the tag's ordinary, free-form help text, so
+// that a struct already documented with tfortools tags for GenerateUsage*
+// doesn't have that help text silently repurposed as a CSV/TSV column
+// header the moment OptToCSV or OptToTSV is enabled.
+func titleTagValue(f reflect.StructField) (string, bool) {
+	_, raw := splitTag(string(f.Tag))
+	title := strings.TrimPrefix(raw, "title=")
+	if title == raw {
+		return "", false
+	}
+	return title, true
+}
+
+// fieldTitle returns the title that should be used for field in the header
+// row of a CSV or TSV table: the value of its tfortools "title=" tag, if it
+// has one, or its name otherwise.
+func fieldTitle(f reflect.StructField) string {
+	if title, ok := titleTagValue(f); ok {
+		return title
+	}
+	return f.Name
+}
+
+// scalarString renders a non-composite reflect.Value as plain text.
+func scalarString(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// cellString renders a single table cell.  A field with pinned formatting
+// (see decoratedText) is rendered using that; composite values (structs,
+// slices, arrays and maps) are otherwise rendered as compact JSON so that
+// they fit on a single line, and everything else is rendered as plain
+// text.
+func cellString(f reflect.StructField, v reflect.Value) (string, error) {
+	if text, ok := decoratedText(f, v); ok {
+		return text, nil
+	}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	default:
+		return scalarString(v), nil
+	}
+}
+
+func writeCSVLike(w io.Writer, data interface{}, comma rune) error {
+	v, err := sliceValue(data, "tocsv")
+	if err != nil {
+		return err
+	}
+	if v.Len() == 0 {
+		return nil
+	}
+	elemType := v.Index(0).Type()
+	if elemType.Kind() != reflect.Struct {
+		return fmt.Errorf("tfortools: tocsv: expected a slice or array of structs, got a slice of %s", elemType.Kind())
+	}
+
+	var fields []reflect.StructField
+	var headers []string
+	for i := 0; i < elemType.NumField(); i++ {
+		f := elemType.Field(i)
+		if !validField(f) {
+			continue
+		}
+		fields = append(fields, f)
+		headers = append(headers, fieldTitle(f))
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	if err := cw.Write(headers); err != nil {
+		return err
+	}
+	for i := 0; i < v.Len(); i++ {
+		row := make([]string, len(fields))
+		for j, f := range fields {
+			cell, err := cellString(f, v.Index(i).FieldByName(f.Name))
+			if err != nil {
+				return err
+			}
+			row[j] = cell
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// toCSVWriter renders data, a slice or array of structs, as a comma
+// separated table.
+func toCSVWriter(w io.Writer, data interface{}) error {
+	return writeCSVLike(w, data, ',')
+}
+
+// toTSVWriter renders data, a slice or array of structs, as a tab
+// separated table.
+func toTSVWriter(w io.Writer, data interface{}) error {
+	return writeCSVLike(w, data, '\t')
+}
+
+// --- YAML -------------------------------------------------------------
+
+func yamlScalar(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.Quote(v.String())
+	default:
+		return scalarString(v)
+	}
+}
+
+func writeYAMLValue(b *strings.Builder, v reflect.Value, indent int) {
+	pad := strings.Repeat("  ", indent)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			b.WriteString(pad + "[]\n")
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			elem := v.Index(i)
+			switch elem.Kind() {
+			case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+				b.WriteString(pad + "-\n")
+				writeYAMLValue(b, elem, indent+1)
+			default:
+				b.WriteString(pad + "- " + yamlScalar(elem) + "\n")
+			}
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !validField(f) {
+				continue
+			}
+			fv := v.Field(i)
+			if text, ok := decoratedText(f, fv); ok {
+				b.WriteString(pad + f.Name + ": " + strconv.Quote(text) + "\n")
+				continue
+			}
+			switch fv.Kind() {
+			case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+				b.WriteString(pad + f.Name + ":\n")
+				writeYAMLValue(b, fv, indent+1)
+			default:
+				b.WriteString(pad + f.Name + ": " + yamlScalar(fv) + "\n")
+			}
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		for _, k := range keys {
+			fv := v.MapIndex(k)
+			switch fv.Kind() {
+			case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+				b.WriteString(pad + fmt.Sprint(k.Interface()) + ":\n")
+				writeYAMLValue(b, fv, indent+1)
+			default:
+				b.WriteString(pad + fmt.Sprint(k.Interface()) + ": " + yamlScalar(fv) + "\n")
+			}
+		}
+	default:
+		b.WriteString(pad + yamlScalar(v) + "\n")
+	}
+}
+
+// toYAMLWriter renders data as YAML.
+func toYAMLWriter(w io.Writer, data interface{}) error {
+	var b strings.Builder
+	writeYAMLValue(&b, reflect.ValueOf(data), 0)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// --- TOML ---------------------------------------------------------------
+
+func tomlScalar(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.Quote(v.String())
+	default:
+		return scalarString(v)
+	}
+}
+
+func writeTOMLStruct(b *strings.Builder, v reflect.Value, table string) {
+	t := v.Type()
+	var nested []int
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !validField(f) {
+			continue
+		}
+		fv := v.Field(i)
+		if text, ok := decoratedText(f, fv); ok {
+			b.WriteString(f.Name + " = " + strconv.Quote(text) + "\n")
+			continue
+		}
+		switch fv.Kind() {
+		case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+			nested = append(nested, i)
+			continue
+		default:
+			b.WriteString(f.Name + " = " + tomlScalar(fv) + "\n")
+		}
+	}
+	for _, i := range nested {
+		f := t.Field(i)
+		fv := v.Field(i)
+		name := table + "." + f.Name
+		switch fv.Kind() {
+		case reflect.Slice, reflect.Array:
+			if fv.Len() > 0 && fv.Index(0).Kind() == reflect.Struct {
+				for j := 0; j < fv.Len(); j++ {
+					b.WriteString("\n[[" + name + "]]\n")
+					writeTOMLStruct(b, fv.Index(j), name)
+				}
+				continue
+			}
+			values := make([]string, fv.Len())
+			for j := 0; j < fv.Len(); j++ {
+				values[j] = tomlScalar(fv.Index(j))
+			}
+			b.WriteString(f.Name + " = [" + strings.Join(values, ", ") + "]\n")
+		case reflect.Struct:
+			b.WriteString("\n[" + name + "]\n")
+			writeTOMLStruct(b, fv, name)
+		}
+	}
+}
+
+// toTOMLWriter renders data as TOML.  If data is a slice or array of
+// structs, each element becomes a row of an array of tables called
+// "item"; if it is a single struct, its fields become top level keys.
+func toTOMLWriter(w io.Writer, data interface{}) error {
+	v := reflect.ValueOf(data)
+	var b strings.Builder
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if v.Index(i).Kind() != reflect.Struct {
+				return fmt.Errorf("tfortools: totoml: expected a slice or array of structs, got a slice of %s", v.Index(i).Kind())
+			}
+			b.WriteString("[[item]]\n")
+			writeTOMLStruct(&b, v.Index(i), "item")
+			if i != v.Len()-1 {
+				b.WriteString("\n")
+			}
+		}
+	case reflect.Struct:
+		writeTOMLStruct(&b, v, "item")
+	default:
+		return fmt.Errorf("tfortools: totoml: expected a struct or a slice or array of structs, got %s", v.Kind())
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// --- XML ------------------------------------------------------------
+
+func xmlEscape(s string) string {
+	r := strings.NewReplacer(
+		"&", "&amp;",
+		"<", "&lt;",
+		">", "&gt;",
+	)
+	return r.Replace(s)
+}
+
+func writeXMLValue(b *strings.Builder, name string, v reflect.Value, indent int) {
+	pad := strings.Repeat("\t", indent)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			writeXMLValue(b, name, v.Index(i), indent)
+		}
+	case reflect.Struct:
+		b.WriteString(pad + "<" + name + ">\n")
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !validField(f) {
+				continue
+			}
+			fv := v.Field(i)
+			if text, ok := decoratedText(f, fv); ok {
+				b.WriteString(pad + "\t<" + f.Name + ">" + xmlEscape(text) + "</" + f.Name + ">\n")
+				continue
+			}
+			writeXMLValue(b, f.Name, fv, indent+1)
+		}
+		b.WriteString(pad + "</" + name + ">\n")
+	default:
+		b.WriteString(pad + "<" + name + ">" + xmlEscape(scalarString(v)) + "</" + name + ">\n")
+	}
+}
+
+// toXMLWriter renders data as XML.  Each element of a top level slice or
+// array is rendered as an <item> element; a top level struct is rendered
+// as a single <item> element.
+func toXMLWriter(w io.Writer, data interface{}) error {
+	v := reflect.ValueOf(data)
+	var b strings.Builder
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		b.WriteString("<items>\n")
+		for i := 0; i < v.Len(); i++ {
+			writeXMLValue(&b, "item", v.Index(i), 1)
+		}
+		b.WriteString("</items>\n")
+	} else {
+		writeXMLValue(&b, "item", v, 0)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfortools
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrTooManyRows is returned by the blocking template functions, such as
+// sort, groupby and tojson, when the number of elements they would need to
+// buffer from an Iterator exceeds Config.MaxBufferedRows.
+var ErrTooManyRows = errors.New("tfortools: too many rows buffered from stream")
+
+// stream is the value OutputToTemplateStream passes to a script as its
+// data, ".".  Template functions that can process an Iterator's elements
+// one at a time, such as filter, select, cols, head and tail, transform or
+// consume a stream lazily; those that cannot, such as sort, groupby and
+// tojson, buffer it into a slice first, via drainToSlice.
+type stream struct {
+	it          Iterator
+	maxBuffered int
+}
+
+func newStream(it Iterator, maxBuffered int) *stream {
+	return &stream{it: it, maxBuffered: maxBuffered}
+}
+
+// drainToSlice reads every remaining element of s into a newly allocated
+// slice, whose element type is inferred from the first element read.  It
+// returns an invalid reflect.Value, with a nil error, if s yields no
+// elements at all.  It returns ErrTooManyRows if s.maxBuffered is positive
+// and more elements are read than that.
+func (s *stream) drainToSlice() (reflect.Value, error) {
+	var result reflect.Value
+	count := 0
+	for {
+		v, ok, err := s.it.Next()
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if !ok {
+			break
+		}
+		if s.maxBuffered > 0 && count >= s.maxBuffered {
+			return reflect.Value{}, ErrTooManyRows
+		}
+		rv := reflect.ValueOf(v)
+		if !result.IsValid() {
+			result = reflect.MakeSlice(reflect.SliceOf(rv.Type()), 0, 0)
+		}
+		result = reflect.Append(result, rv)
+		count++
+	}
+	return result, nil
+}
+
+// drainIfStream returns data unchanged unless it is a *stream, in which
+// case it reads the stream to exhaustion via drainToSlice and returns the
+// resulting slice instead.  It lets a template function that can only work
+// on a fully materialized slice or array, such as sum or tocsv, accept a
+// stream transparently, rather than every such function having to test for
+// *stream itself.
+func drainIfStream(data interface{}) (interface{}, error) {
+	s, ok := data.(*stream)
+	if !ok {
+		return data, nil
+	}
+	buffered, err := s.drainToSlice()
+	if err != nil {
+		return nil, err
+	}
+	if !buffered.IsValid() {
+		return []struct{}{}, nil
+	}
+	return buffered.Interface(), nil
+}
+
+// filterIterator lazily applies a matcher, as used by filterBy, to the
+// elements of src, yielding only those that match.
+type filterIterator struct {
+	src   Iterator
+	field string
+	value string
+	match matcher
+}
+
+func (it *filterIterator) Next() (interface{}, bool, error) {
+	for {
+		v, ok, err := it.src.Next()
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		fv, err := fieldByName(reflect.ValueOf(v), it.field)
+		if err != nil {
+			return nil, false, err
+		}
+		if it.match(plainFormat(fv), it.value) {
+			return v, true, nil
+		}
+	}
+}
+
+// colsIterator lazily projects the named fields of src's elements onto a
+// new struct type, derived from the first element read.
+type colsIterator struct {
+	src     Iterator
+	fields  []string
+	newType reflect.Type
+}
+
+func (it *colsIterator) Next() (interface{}, bool, error) {
+	v, ok, err := it.src.Next()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	rv := reflect.ValueOf(v)
+	if it.newType == nil {
+		elemType := rv.Type()
+		sfs := make([]reflect.StructField, len(it.fields))
+		for i, field := range it.fields {
+			f, ok := elemType.FieldByName(field)
+			if !ok {
+				return nil, false, fmt.Errorf("tfortools: cols: no such field %s", field)
+			}
+			sfs[i] = f
+		}
+		it.newType = reflect.StructOf(sfs)
+	}
+	out := reflect.New(it.newType).Elem()
+	for _, field := range it.fields {
+		out.FieldByName(field).Set(rv.FieldByName(field))
+	}
+	return out.Interface(), true, nil
+}
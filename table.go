@@ -0,0 +1,206 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfortools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// altFormat renders v the way tablexalt, htablexalt and selectalt do:
+// strings are quoted and unsigned integers are rendered in hexadecimal.
+// Every other kind falls back to the default formatting used by fmt.
+func altFormat(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("%q", v.String())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return fmt.Sprintf("%#x", v.Uint())
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// tableRows builds the rows of a tablex/htablex table, deriving the header
+// row from the first element of data.  Laying out a table requires knowing
+// every row up front, to compute each column's width, so a *stream is
+// drained into a slice first, via sliceValue, exactly as sort, groupby and
+// tojson already drain one: subject to cfg's MaxBufferedRows, and with
+// ErrTooManyRows if data has more rows than that.
+func tableRows(data interface{}, fn string, alt bool) (headers []string, rows [][]string, err error) {
+	v, err := sliceValue(data, fn)
+	if err != nil {
+		return nil, nil, err
+	}
+	if v.Len() == 0 {
+		return nil, nil, nil
+	}
+
+	elemType := v.Index(0).Type()
+	var fields []reflect.StructField
+	for i := 0; i < elemType.NumField(); i++ {
+		f := elemType.Field(i)
+		if !validField(f) {
+			continue
+		}
+		fields = append(fields, f)
+	}
+
+	headers = make([]string, len(fields))
+	for i, f := range fields {
+		headers[i] = f.Name
+	}
+
+	rows = make([][]string, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		row := make([]string, len(fields))
+		for j, f := range fields {
+			fv, err := fieldByName(v.Index(i), f.Name)
+			if err != nil {
+				return nil, nil, err
+			}
+			row[j] = formatCell(f, fv, alt)
+		}
+		rows[i] = row
+	}
+	return headers, rows, nil
+}
+
+// formatCell renders a single table or select cell, honoring any pinned
+// formatting (a tfortools "fmt=" tag, or a TextMarshaler, Stringer or
+// BinaryMarshaler implementation) before falling back to plainFormat or,
+// for the "alt" family of functions, altFormat.
+func formatCell(f reflect.StructField, v reflect.Value, alt bool) string {
+	if text, ok := decoratedText(f, v); ok {
+		return text
+	}
+	if alt {
+		return altFormat(v)
+	}
+	return plainFormat(v)
+}
+
+func plainFormat(v reflect.Value) string {
+	return fmt.Sprint(v.Interface())
+}
+
+func renderTable(headers []string, rows [][]string, minwidth, tabwidth, padding int) string {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, minwidth, tabwidth, padding, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	tw.Flush()
+	return buf.String()
+}
+
+func table2x(data interface{}, minwidth, tabwidth, padding int, fn string, alt bool) (string, error) {
+	headers, rows, err := tableRows(data, fn, alt)
+	if err != nil {
+		return "", err
+	}
+	if headers == nil {
+		return "", nil
+	}
+	return renderTable(headers, rows, minwidth, tabwidth, padding), nil
+}
+
+// tablex renders data, a slice or array of structs, as a table, one row
+// per element, with a header row giving the name of each field.
+func tablex(data interface{}, minwidth, tabwidth, padding int) (string, error) {
+	return table2x(data, minwidth, tabwidth, padding, "tablex", false)
+}
+
+// tablexalt behaves like tablex but renders each cell using the same
+// alternate formatting rules as selectalt.
+func tablexalt(data interface{}, minwidth, tabwidth, padding int) (string, error) {
+	return table2x(data, minwidth, tabwidth, padding, "tablexalt", true)
+}
+
+func htable2x(data interface{}, minwidth, tabwidth, padding int, fn string, alt bool) (string, error) {
+	headers, rows, err := tableRows(data, fn, alt)
+	if err != nil {
+		return "", err
+	}
+	if headers == nil {
+		return "", nil
+	}
+
+	blocks := make([]string, len(rows))
+	for i, row := range rows {
+		fields := make([]string, len(headers))
+		for j, h := range headers {
+			fields[j] = h + ":\t" + row[j]
+		}
+		var buf bytes.Buffer
+		tw := tabwriter.NewWriter(&buf, minwidth, tabwidth, padding, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(fields, "\n"))
+		tw.Flush()
+		blocks[i] = strings.TrimRight(buf.String(), "\n")
+	}
+	return strings.Join(blocks, "\n\n"), nil
+}
+
+// htablex renders data, a slice or array of structs, as a series of
+// vertical, field-per-line, tables, one per element, separated by blank
+// lines.
+func htablex(data interface{}, minwidth, tabwidth, padding int) (string, error) {
+	return htable2x(data, minwidth, tabwidth, padding, "htablex", false)
+}
+
+// htablexalt behaves like htablex but renders each value using the same
+// alternate formatting rules as selectalt.
+func htablexalt(data interface{}, minwidth, tabwidth, padding int) (string, error) {
+	return htable2x(data, minwidth, tabwidth, padding, "htablexalt", true)
+}
+
+// tojson renders data as indented JSON.  Fields carrying a pinned
+// tfortools "fmt=" tag, or whose type implements encoding.TextMarshaler,
+// fmt.Stringer or encoding.BinaryMarshaler, are rendered as the strings
+// that formatting produces rather than their native JSON encoding.
+func tojson(data interface{}) (string, error) {
+	return tojsonDepth(data, 0)
+}
+
+// tojsonDepth behaves like tojson, except that it gives up, returning
+// ErrMaxDepthExceeded, if decorating data requires recursing more than
+// maxDepth levels deep into its fields.  maxDepth of zero means
+// unlimited.  It backs the "tojson" template function, which binds
+// maxDepth to Config.MaxTemplateDepth when it is registered.
+func tojsonDepth(data interface{}, maxDepth int) (string, error) {
+	data, err := drainIfStream(data)
+	if err != nil {
+		return "", err
+	}
+
+	out := data
+	if v := reflect.ValueOf(data); v.IsValid() {
+		dv, err := decorateDepth(v, 0, maxDepth)
+		if err != nil {
+			return "", err
+		}
+		out = dv.Interface()
+	}
+	b, err := json.MarshalIndent(out, "", "\t")
+	if err != nil {
+		return "", fmt.Errorf("tfortools: tojson: %v", err)
+	}
+	return string(b), nil
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfortools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"text/template"
+)
+
+// OutputToTemplate executes the template contained in script against data,
+// writing the results to w.  name is used to identify the template in any
+// error messages it produces.  cfg determines which extra template
+// functions, beyond those provided by text/template itself, are made
+// available to script.  If cfg is nil, every function tfortools provides
+// is made available.  If cfg sets MaxExecutionTime or MaxOutputBytes,
+// OutputToTemplate enforces them, returning ErrTemplateTimeout or
+// ErrOutputTruncated as appropriate; callers that need to supply their own
+// context.Context, e.g., to cancel script early, should use
+// OutputToTemplateContext instead.
+func OutputToTemplate(w io.Writer, name, script string, data interface{}, cfg *Config) error {
+	return OutputToTemplateContext(context.Background(), w, name, script, data, cfg)
+}
+
+// OutputToTemplateContext behaves like OutputToTemplate, except that it
+// executes script with ctx, so that OutputToTemplateContext returns
+// ErrTemplateTimeout as soon as ctx is cancelled or its deadline passes, in
+// addition to doing so after cfg.MaxExecutionTime, if that is set.
+//
+// Neither ctx nor MaxExecutionTime bounds anything but how long the caller
+// waits: text/template offers no way to interrupt a template already
+// executing, so script is merely abandoned, not stopped, once the
+// deadline passes.  It keeps running, and keeps writing to w, on its own
+// goroutine, for as long as it takes to finish — or forever, for a script
+// that never does, such as one that calls a slow or looping custom
+// function, or filterRegexp with a pathological pattern.  A caller
+// running scripts of unknown provenance, the case OptSandboxDefaults is
+// meant for, should treat MaxExecutionTime as a latency bound only: it
+// does not reclaim the CPU time or memory such a script goes on using,
+// and repeated timeouts accumulate one abandoned goroutine each.
+//
+// If cfg sets MaxRangeIterations, it bounds the ordinary, slice-based form
+// of data the same way it bounds an Iterator passed to
+// OutputToTemplateStream: a data slice or array longer than the limit is
+// rejected before script even runs, and every "range-consumed" template
+// function — filter, sort, cols, sum, tocsv and the like — rejects, with
+// ErrTooManyRangeIterations, any slice or array it is given that is
+// longer than the limit, wrapped as the cause of the "unable to execute
+// template" error that reaches the caller.
+func OutputToTemplateContext(ctx context.Context, w io.Writer, name, script string, data interface{}, cfg *Config) error {
+	if cfg == nil {
+		cfg = NewConfig(OptAllFns)
+	}
+
+	if cfg.MaxRangeIterations > 0 {
+		if v := reflect.ValueOf(data); v.IsValid() && (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Len() > cfg.MaxRangeIterations {
+			return ErrTooManyRangeIterations
+		}
+	}
+
+	tmpl, err := template.New(name).Funcs(cfg.funcMap).Parse(script)
+	if err != nil {
+		return fmt.Errorf("tfortools: unable to parse template: %v", err)
+	}
+
+	if cfg.MaxExecutionTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.MaxExecutionTime)
+		defer cancel()
+	}
+
+	dest := w
+	if cfg.MaxOutputBytes > 0 {
+		dest = &limitedWriter{w: w, max: cfg.MaxOutputBytes}
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- tmpl.Execute(dest, data)
+	}()
+
+	select {
+	case err := <-done:
+		switch err {
+		case nil:
+			return nil
+		case ErrOutputTruncated:
+			return ErrOutputTruncated
+		default:
+			return fmt.Errorf("tfortools: unable to execute template: %v", err)
+		}
+	case <-ctx.Done():
+		return ErrTemplateTimeout
+	}
+}
+
+// OutputToTemplateStream behaves like OutputToTemplate, except that it
+// takes an Iterator rather than a fully materialized slice or array.
+// Functions that can process its elements one at a time, such as filter,
+// select, cols, head and tail, consume it lazily, so that script can be
+// run over a dataset too large to hold in memory all at once.  Functions
+// that cannot, such as sort, groupby and tojson, buffer it into a slice
+// first, subject to cfg's MaxBufferedRows.  If cfg sets
+// MaxRangeIterations, it is also enforced here, over the whole pipeline of
+// functions script applies to it, with ErrTooManyRangeIterations surfacing
+// as the cause of the "unable to execute template" error it is wrapped in.
+func OutputToTemplateStream(w io.Writer, name, script string, it Iterator, cfg *Config) error {
+	if cfg == nil {
+		cfg = NewConfig(OptAllFns)
+	}
+	if cfg.MaxRangeIterations > 0 {
+		it = &countingIterator{src: it, max: cfg.MaxRangeIterations}
+	}
+	return OutputToTemplate(w, name, script, newStream(it, cfg.MaxBufferedRows), cfg)
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfortools
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// GenerateUsageUndecorated returns a Go-like description of the type of
+// data.  It's intended to be embedded, by applications, into their own
+// usage text for the -f or --format style option that they implement on
+// top of tfortools.
+func GenerateUsageUndecorated(data interface{}) string {
+	return describeType(reflect.TypeOf(data))
+}
+
+// GenerateUsageDecorated generates a block of usage text for flag, a
+// command line option such as "-f" or "--format", that describes both the
+// structure of data, the type on which a template passed to flag will
+// operate, and the extra template functions enabled by cfg.  The returned
+// text is suitable for inclusion, verbatim, in a command's help or man
+// page.
+func GenerateUsageDecorated(flag string, data interface{}, cfg *Config) string {
+	if cfg == nil {
+		cfg = NewConfig(OptAllFns)
+	}
+
+	var help []string
+	for _, name := range cfg.names {
+		help = append(help, cfg.help[name])
+	}
+
+	return fmt.Sprintf(
+		"The template passed to the -%s option operates on a\n\n%s\n\nSome new functions have been added to Go's template language\n\n%s",
+		flag, GenerateUsageUndecorated(data), strings.Join(help, "\n\n"))
+}